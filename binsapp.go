@@ -3,10 +3,12 @@
 package binsanity
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 
 	"github.com/urfave/cli/v2"
 )
@@ -24,11 +26,91 @@ The most common use case is to simply run "binsanity ASSET_DIR" in the Go
 source directory where you plan to use the asset functions.  ASSET_DIR is the
 directory of data to be included 
 
+An ASSET_DIR named like a command (init, extract, inspect, diff, verify,
+migrate, regen or help) would run that command instead, so give it as
+"./init" and so on.
+
 The default values will usually work if you have an up-to-date go.mod file in
 the current directory or above it.  The files generated in the working dir
 will be binsanity.go and binsanity_test.go.
 
-The generated source and text files will be overwritten if they exist.
+The generated source and test files will be overwritten if they exist, but
+only if binsanity generated them: files without the "Code generated by
+binsanity" marker are left alone unless you use --force.
+
+With --check nothing is written: binsanity exits nonzero, listing the added
+(+), removed (-) and changed (~) assets, if the files are out of date.
+
+With --dry-run nothing is written either: the assets are read and compressed,
+and listed with their original and compressed sizes and sha256 sums.
+
+With --json the full result is printed as JSON instead, in any mode: every
+asset with its source path, sizes, codec and sum, the totals, the elapsed time
+and any files skipped for not being regular files.
+
+With --include and --exclude, only assets whose names match, or don't match,
+the patterns are embedded.  Patterns are as for Go's path.Match; they also
+match parent dirs, and without a slash they match the last part of the name:
+"--exclude '*.bak' --exclude drafts".
+
+To generate several sets of files at once, list them in a project config
+file (default: binsanity.json) and run binsanity with no ASSET_DIR, or with
+"-c CONFIG".  Paths are relative to the config file:
+
+  {"jobs": [
+    {"dir": "web/assets", "output": "web/binsanity.go", "exclude": ["*.bak"]},
+    {"dir": "sql", "output": "db/sql.go", "package": "db", "split_size": 1000000}
+  ]}
+
+Each job also takes "package", "module", "include", "compat", "meta" and
+"fs".  The --jobs, --force, --check, --dry-run and --json options apply to all
+of them, and the first job to fail stops the run.  Options for what to
+generate, such as --output or --exclude, belong in the jobs and are an error
+here.
+
+Each generated source file records the options it was generated with, so
+"binsanity regen FILE.go" regenerates it the same way, from anywhere.
+
+With --watch binsanity keeps running until interrupted, polling ASSET_DIR
+and regenerating shortly after anything in it changes.  A line is printed for
+each run.
+
+To set up a package for "go generate", run "binsanity init ASSET_DIR" with
+any other options in its directory: the files are generated for the first
+time, and then a "//go:generate binsanity ..." directive is added to the file
+with the package doc comment, or else to doc.go (created if need be), unless
+there is one already for the same output file and asset dir.  One with other
+options is left as is, with a warning.
+
+To see what is in a generated file, "binsanity inspect FILE.go" lists its
+assets, and the package, version and options it was generated with.
+
+To recover the assets from a generated file, use "binsanity extract FILE.go
+OUTDIR".  If the test file is there too, the assets are checked against the
+sums in it, all before any are written.
+
+To see how a generated file differs from its asset dir, use "binsanity diff
+FILE.go DIR", which lists the assets added, removed and changed since it was
+generated.  With --unified, the changes to text assets are shown as well.
+
+To check a generated file without running its tests, use "binsanity verify
+FILE.go": every asset is decoded and checked against the sums in the test
+file, and any corrupt ones are listed with a nonzero exit.
+
+To move from go-bindata, use "binsanity migrate bindata.go ASSET_DIR": the
+assets are recovered from bindata.go into ASSET_DIR ("assets" by default) and
+generated for as usual.  Add --compat to also generate the go-bindata
+functions binsanity otherwise doesn't, AssetInfo and AssetString, so existing
+callers keep working, and --meta to keep the modes and modification times
+go-bindata recorded.  Nothing is written unless the options check out.
+
+The generated AssetDir, WalkAssets and GlobAssets functions navigate the
+assets as a directory tree.  Add --fs to also generate SubAssets, which
+returns an AssetsFS view of a directory in it, in which names are relative
+to the directory, that is also an fs.FS.  RestoreAsset and RestoreAssets
+write assets back to disk at runtime.  Add --meta to record the mode and
+modification time of each file, so they are restored too, over existing
+files as well.
 
 Hats off to Jim Teeuwen for doing the much more powerful version of this thing
 first.  If you aren't too sensitive about testing you should probably use
@@ -46,55 +128,247 @@ func RunApp(args []string) {
 	// docopt's so there's that). We avoid the craziness of things like
 	// cli.HandleExitCoder(cli.Exit(err, 1)) by doing our own craziness.
 	cfg := &Config{}
+	as_json := false
+	watch := false
+	unified := false
+	flags := append(genFlags(cfg),
+		&cli.StringFlag{
+			Name:     "config",
+			Aliases:  []string{"c"},
+			Value:    "",
+			Usage:    "run the jobs in this project config file",
+			Required: false,
+		},
+		&cli.BoolFlag{
+			Name:        "watch",
+			Usage:       "keep running, regenerating when the assets change",
+			Destination: &watch,
+			Required:    false,
+		},
+	)
 	app := &cli.App{
 		Name:        "binsanity",
 		Usage:       "embed assets with testing",
-		UsageText:   "binsanity [options] ASSET_DIR",
+		UsageText:   "binsanity [options] ASSET_DIR\nbinsanity [options] [-c CONFIG]\nbinsanity regen [options] FILE.go\nbinsanity init [options] ASSET_DIR\nbinsanity extract [options] FILE.go OUTDIR\nbinsanity inspect [options] FILE.go\nbinsanity diff [options] FILE.go DIR\nbinsanity verify [options] FILE.go\nbinsanity migrate [options] BINDATA.go [ASSET_DIR]",
 		Description: AppDescription,
 		Version:     Version,
 		Writer:      OutWriter,
 		ErrWriter:   ErrWriter,
-		Flags: []cli.Flag{
-			&cli.StringFlag{
-				Name:        "output",
-				Aliases:     []string{"o"},
-				Value:       "binsanity.go",
-				Usage:       "output file for generated Go source",
-				Destination: &(cfg.File),
-				Required:    false,
-			},
-			&cli.StringFlag{
-				Name:        "module",
-				Aliases:     []string{"m"},
-				Value:       "",
-				Usage:       "module identifier (see description)",
-				Destination: &(cfg.Module),
-				Required:    false,
-			},
-			&cli.StringFlag{
-				Name:        "package",
-				Aliases:     []string{"p"},
-				Value:       "",
-				Usage:       "package name (see description)",
-				Destination: &(cfg.Package),
-				Required:    false,
-			},
-		},
+		Flags:       append(append(flags, runFlags(cfg, &as_json)...), checkFlags(cfg)...),
 		Action: func(cCtx *cli.Context) error {
+			cfg.Include = cCtx.StringSlice("include")
+			cfg.Exclude = cCtx.StringSlice("exclude")
+
+			// A project file is used if given, or if there's nothing else.
+			project := cCtx.String("config")
+			if project == "" && cCtx.NArg() == 0 {
+				if _, err := os.Stat(ProjectFile); err == nil {
+					project = ProjectFile
+				}
+			}
+			if project != "" {
+				if cCtx.NArg() != 0 {
+					return errors.New("No ASSET_DIR allowed with a config file.")
+				}
+				// What to generate is up to the jobs, not the command line.
+				for _, flag := range genFlags(&Config{}) {
+					if name := flag.Names()[0]; cCtx.IsSet(name) {
+						return fmt.Errorf("No --%s allowed with a config file.", name)
+					}
+				}
+				if watch {
+					return errors.New("Can't watch with a config file.")
+				}
+				return runProject(project, cfg, as_json)
+			}
+
 			// Surprised this isn't built in to the app spec...
 			if cCtx.NArg() != 1 {
 				return errors.New("Single arg required: ASSET_DIR")
 			}
 			cfg.Dir = cCtx.Args().Get(0)
-
-			res, err := Process(cfg)
-			if err != nil {
-				return err
+			if watch {
+				runWatch(cfg, as_json)
+				return nil
 			}
-
-			fmt.Fprintln(OutWriter, res.String())
-
-			return nil
+			return run(cfg, as_json)
+		},
+		Commands: []*cli.Command{
+			{
+				Name:      "regen",
+				Usage:     "regenerate a file with the config recorded in it",
+				UsageText: "binsanity regen [options] FILE.go",
+				Flags:     append(runFlags(cfg, &as_json), checkFlags(cfg)...),
+				Action: func(cCtx *cli.Context) error {
+					if cCtx.NArg() != 1 {
+						return errors.New("Single arg required: FILE.go")
+					}
+					file := cCtx.Args().Get(0)
+					rec, err := ReadConfig(file)
+					if err != nil {
+						return err
+					}
+					if rec.Version != Version {
+						fmt.Fprintf(ErrWriter,
+							"Generated by binsanity %s, regenerating with %s.\n",
+							rec.Version, Version)
+					}
+					rcfg := rec.Config(file)
+					rcfg.Jobs = cfg.Jobs
+					rcfg.Force = cfg.Force
+					rcfg.Check = cfg.Check
+					rcfg.DryRun = cfg.DryRun
+					return run(rcfg, as_json)
+				},
+			},
+			{
+				Name:      "extract",
+				Usage:     "write the assets in a generated file back out",
+				UsageText: "binsanity extract [options] FILE.go OUTDIR",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:        "force",
+						Usage:       "overwrite existing files",
+						Destination: &(cfg.Force),
+						Required:    false,
+					},
+				},
+				Action: func(cCtx *cli.Context) error {
+					if cCtx.NArg() != 2 {
+						return errors.New("Two args required: FILE.go OUTDIR")
+					}
+					res, err := Extract(cCtx.Args().Get(0), cCtx.Args().Get(1), cfg.Force)
+					if err != nil {
+						return err
+					}
+					fmt.Fprintln(OutWriter, res.String())
+					return nil
+				},
+			},
+			{
+				Name:      "inspect",
+				Usage:     "list the assets in a generated file",
+				UsageText: "binsanity inspect [options] FILE.go",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:        "json",
+						Usage:       "print the assets as JSON",
+						Destination: &as_json,
+						Required:    false,
+					},
+				},
+				Action: func(cCtx *cli.Context) error {
+					if cCtx.NArg() != 1 {
+						return errors.New("Single arg required: FILE.go")
+					}
+					ins, err := Inspect(cCtx.Args().Get(0))
+					if err != nil {
+						return err
+					}
+					if as_json {
+						return printJSON(ins)
+					}
+					fmt.Fprint(OutWriter, ins.String())
+					return nil
+				},
+			},
+			{
+				Name:      "diff",
+				Usage:     "compare the assets in a generated file with a dir",
+				UsageText: "binsanity diff [options] FILE.go DIR",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:        "unified",
+						Aliases:     []string{"u"},
+						Usage:       "also show what changed in text assets",
+						Destination: &unified,
+						Required:    false,
+					},
+				},
+				Action: func(cCtx *cli.Context) error {
+					if cCtx.NArg() != 2 {
+						return errors.New("Two args required: FILE.go DIR")
+					}
+					d, err := Diff(cCtx.Args().Get(0), cCtx.Args().Get(1))
+					if err != nil {
+						return err
+					}
+					fmt.Fprintln(OutWriter, d.String())
+					if unified {
+						text, err := d.Unified()
+						if err != nil {
+							return err
+						}
+						fmt.Fprint(OutWriter, text)
+					}
+					return nil
+				},
+			},
+			{
+				Name:      "verify",
+				Usage:     "check the assets in a generated file against its test sums",
+				UsageText: "binsanity verify [options] FILE.go",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:        "json",
+						Usage:       "print the result as JSON",
+						Destination: &as_json,
+						Required:    false,
+					},
+				},
+				Action: func(cCtx *cli.Context) error {
+					if cCtx.NArg() != 1 {
+						return errors.New("Single arg required: FILE.go")
+					}
+					file := cCtx.Args().Get(0)
+					v, err := Verify(file)
+					if err != nil {
+						return err
+					}
+					if as_json {
+						err = printJSON(v)
+					} else {
+						fmt.Fprintln(OutWriter, v.String())
+					}
+					if err == nil && !v.OK() {
+						err = fmt.Errorf("%s: %d corrupt assets.", file, len(v.Corrupt))
+					}
+					return err
+				},
+			},
+			{
+				Name:      "migrate",
+				Usage:     "recover the assets from a go-bindata file and generate for them",
+				UsageText: "binsanity migrate [options] BINDATA.go [ASSET_DIR]",
+				Flags:     append(genFlags(cfg), runFlags(cfg, &as_json)...),
+				Action: func(cCtx *cli.Context) error {
+					if cCtx.NArg() < 1 || cCtx.NArg() > 2 {
+						return errors.New("One or two args required: BINDATA.go [ASSET_DIR]")
+					}
+					cfg.Dir = "assets"
+					if cCtx.NArg() == 2 {
+						cfg.Dir = cCtx.Args().Get(1)
+					}
+					cfg.Include = cCtx.StringSlice("include")
+					cfg.Exclude = cCtx.StringSlice("exclude")
+					return runMigrate(cCtx.Args().Get(0), cfg, as_json)
+				},
+			},
+			{
+				Name:      "init",
+				Usage:     "add a go:generate directive and generate for the first time",
+				UsageText: "binsanity init [options] ASSET_DIR",
+				Flags:     append(genFlags(cfg), runFlags(cfg, &as_json)...),
+				Action: func(cCtx *cli.Context) error {
+					if cCtx.NArg() != 1 {
+						return errors.New("Single arg required: ASSET_DIR")
+					}
+					cfg.Dir = cCtx.Args().Get(0)
+					cfg.Include = cCtx.StringSlice("include")
+					cfg.Exclude = cCtx.StringSlice("exclude")
+					return runInit(cfg, as_json)
+				},
+			},
 		},
 	}
 
@@ -104,3 +378,302 @@ func RunApp(args []string) {
 	}
 
 }
+
+// genFlags returns the flags for what to generate, setting values in cfg.
+// The include and exclude patterns have to be read from the context.
+func genFlags(cfg *Config) []cli.Flag {
+
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:        "output",
+			Aliases:     []string{"o"},
+			Value:       "binsanity.go",
+			Usage:       "output file for generated Go source",
+			Destination: &(cfg.File),
+			Required:    false,
+		},
+		&cli.StringFlag{
+			Name:        "module",
+			Aliases:     []string{"m"},
+			Value:       "",
+			Usage:       "module identifier (see description)",
+			Destination: &(cfg.Module),
+			Required:    false,
+		},
+		&cli.StringFlag{
+			Name:        "package",
+			Aliases:     []string{"p"},
+			Value:       "",
+			Usage:       "package name (see description)",
+			Destination: &(cfg.Package),
+			Required:    false,
+		},
+		&cli.IntFlag{
+			Name:        "split-size",
+			Value:       0,
+			Usage:       "split data into files of about this many bytes",
+			Destination: &(cfg.SplitSize),
+			Required:    false,
+		},
+		&cli.StringSliceFlag{
+			Name:     "include",
+			Usage:    "only include assets matching this pattern (repeatable)",
+			Required: false,
+		},
+		&cli.StringSliceFlag{
+			Name:     "exclude",
+			Usage:    "exclude assets matching this pattern (repeatable)",
+			Required: false,
+		},
+		&cli.BoolFlag{
+			Name:        "compat",
+			Usage:       "also generate go-bindata compatible functions",
+			Destination: &(cfg.Compat),
+			Required:    false,
+		},
+		&cli.BoolFlag{
+			Name:        "meta",
+			Usage:       "record file modes and modification times",
+			Destination: &(cfg.Meta),
+			Required:    false,
+		},
+		&cli.BoolFlag{
+			Name:        "fs",
+			Usage:       "also generate AssetsFS and SubAssets, an fs.FS view",
+			Destination: &(cfg.FS),
+			Required:    false,
+		},
+	}
+
+}
+
+// runFlags returns the flags for how to run Process, rather than what to
+// generate, setting values in cfg and as_json.
+func runFlags(cfg *Config, as_json *bool) []cli.Flag {
+
+	return []cli.Flag{
+		&cli.IntFlag{
+			Name:        "jobs",
+			Aliases:     []string{"j"},
+			Value:       0,
+			Usage:       "parallel file workers (default: one per CPU)",
+			Destination: &(cfg.Jobs),
+			Required:    false,
+		},
+		&cli.BoolFlag{
+			Name:        "force",
+			Usage:       "overwrite output files not generated by binsanity",
+			Destination: &(cfg.Force),
+			Required:    false,
+		},
+		&cli.BoolFlag{
+			Name:        "json",
+			Usage:       "print the result as JSON",
+			Destination: as_json,
+			Required:    false,
+		},
+	}
+
+}
+
+// checkFlags returns the flags for running Process without writing anything,
+// setting values in cfg.
+func checkFlags(cfg *Config) []cli.Flag {
+
+	return []cli.Flag{
+		&cli.BoolFlag{
+			Name:        "check",
+			Usage:       "write nothing, fail if the output is out of date",
+			Destination: &(cfg.Check),
+			Required:    false,
+		},
+		&cli.BoolFlag{
+			Name:        "dry-run",
+			Usage:       "write nothing, list the assets that would be embedded",
+			Destination: &(cfg.DryRun),
+			Required:    false,
+		},
+	}
+
+}
+
+// run calls Process with cfg and prints the Result, as JSON if as_json is
+// set.  Stale output is an error.
+func run(cfg *Config, as_json bool) error {
+
+	res, err := Process(cfg)
+	if err != nil {
+		return err
+	}
+
+	if as_json {
+		if err := printJSON(res); err != nil {
+			return err
+		}
+	} else {
+		printResult(cfg, res, "")
+	}
+	if res.Stale {
+		return errors.New("Generated files are out of date.")
+	}
+	return nil
+
+}
+
+// runWatch watches the assets for cfg until interrupted, printing a line
+// for each result, as JSON if as_json is set, or error.
+func runWatch(cfg *Config, as_json bool) {
+
+	WatchUntilInterrupt(cfg, func(res *Result, err error) {
+		if err != nil {
+			fmt.Fprintln(ErrWriter, err)
+			return
+		}
+		if as_json {
+			b, _ := json.Marshal(res) // can't fail for a Result
+			fmt.Fprintln(OutWriter, string(b))
+			return
+		}
+		line := res.String()
+		if res.Stale {
+			line += ", stale"
+		}
+		fmt.Fprintln(OutWriter, line)
+	})
+
+}
+
+// runInit runs Process as in run, and once that has succeeded adds a
+// go:generate directive for cfg to the output dir, unless there is one for
+// the same output file and asset dir already.  A different directive already there is reported, as go generate
+// would then not do what this run did.
+func runInit(cfg *Config, as_json bool) error {
+
+	if cfg.File == "" {
+		cfg.File = "binsanity.go"
+	}
+	if err := run(cfg, as_json); err != nil {
+		return err
+	}
+	pkg := cfg.Package
+	if pkg == "" {
+		var err error
+		pkg, err = FindPackage(cfg.File)
+		if err != nil {
+			return err
+		}
+	}
+	directive, err := GenerateDirective(cfg)
+	if err != nil {
+		return err
+	}
+	file, existing, err := AddDirective(filepath.Dir(cfg.File), pkg, directive)
+	if err != nil {
+		return err
+	}
+	switch existing {
+	case "":
+		fmt.Fprintf(ErrWriter, "Added to %s: %s\n", file, directive)
+	case directive:
+		fmt.Fprintf(ErrWriter, "Already in %s: %s\n", file, directive)
+	default:
+		fmt.Fprintf(ErrWriter, "Different directive in %s, left as is: %s\n",
+			file, existing)
+		fmt.Fprintf(ErrWriter, "This run used: %s\n", directive)
+	}
+	return nil
+
+}
+
+// runMigrate migrates the go-bindata file at file with cfg and prints the
+// Result as in run, with a reminder to remove the go-bindata file if it is
+// in the same directory as the generated code.
+func runMigrate(file string, cfg *Config, as_json bool) error {
+
+	res, err := Migrate(file, cfg)
+	if err != nil {
+		return err
+	}
+	if as_json {
+		if err := printJSON(res); err != nil {
+			return err
+		}
+	} else {
+		printResult(cfg, res, "")
+	}
+	if filepath.Dir(filepath.Clean(file)) == filepath.Dir(filepath.Clean(cfg.File)) {
+		fmt.Fprintf(ErrWriter, "Remove %s before building: it defines the same functions.\n", file)
+	}
+	return nil
+
+}
+
+// runProject runs the jobs in the project config file at file, with the run
+// options in opts, and prints their results: each prefixed by its output
+// file, or as a JSON list.  It stops at the first job that fails.
+func runProject(file string, opts *Config, as_json bool) error {
+
+	proj, err := ReadProject(file)
+	if err != nil {
+		return err
+	}
+	results := []*JobResult{}
+	for idx, job := range proj.Jobs {
+		cfg := proj.Config(job)
+		cfg.Jobs = opts.Jobs
+		cfg.Force = opts.Force
+		cfg.Check = opts.Check
+		cfg.DryRun = opts.DryRun
+
+		res, err := Process(cfg)
+		if err == nil && res.Stale {
+			err = errors.New("Generated files are out of date.")
+		}
+		if res != nil {
+			results = append(results, &JobResult{File: cfg.File, Result: res})
+			if !as_json {
+				printResult(cfg, res, cfg.File+": ")
+			}
+		}
+		if err != nil {
+			err = fmt.Errorf("Job %d (%s): %w", idx+1, cfg.File, err)
+			if as_json {
+				if perr := printJSON(results); perr != nil {
+					return fmt.Errorf("%w (and printing results: %v)", err, perr)
+				}
+			}
+			return err
+		}
+	}
+	if as_json {
+		return printJSON(results)
+	}
+	return nil
+
+}
+
+// printResult prints res from Process with cfg, with the summary line after
+// any table and prefixed with prefix, and any changes after it.
+func printResult(cfg *Config, res *Result, prefix string) {
+
+	if cfg.DryRun {
+		fmt.Fprint(OutWriter, res.Table())
+	}
+	fmt.Fprintln(OutWriter, prefix+res.String())
+	if res.Stale {
+		fmt.Fprintln(OutWriter, res.Changes.String())
+	}
+
+}
+
+// printJSON prints v as indented JSON.
+func printJSON(v interface{}) error {
+
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(OutWriter, string(b))
+	return err
+
+}
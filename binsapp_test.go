@@ -3,6 +3,7 @@ package binsanity_test
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -102,15 +103,17 @@ func TestRunAppOk(t *testing.T) {
 	assert := assert.New(t)
 
 	// By passing in the right args we can recreate the example file 1:1 in
-	// a different location.
+	// a different location, with the assets in the same place relative to
+	// it as they are recorded that way.
 	tdir := t.TempDir()
+	CopyTree(t, ExampleAssetDir, filepath.Join(tdir, "assets"))
 	file := filepath.Join(tdir, "binsanity.go")
 	args := []string{
 		"appname",
 		"--package=main",
 		"--module=biztos.com/example",
 		"--output=" + file,
-		ExampleAssetDir,
+		filepath.Join(tdir, "assets"),
 	}
 
 	exited := false
@@ -128,7 +131,7 @@ func TestRunAppOk(t *testing.T) {
 
 	binsanity.RunApp(args)
 	assert.False(exited, "did not exit through func")
-	assert.Equal("files: 3, bytes: 46\n", stdout.String(), "stdout")
+	assert.Equal("files: 4, bytes: 58, saved: 52\n", stdout.String(), "stdout")
 	assert.Equal("", stderr.String(), "stderr")
 
 	// Check the code file.
@@ -186,3 +189,172 @@ func difflines(f1, f2 string) string {
 
 	return "oops programmer error"
 }
+
+func TestRunAppDryRun(t *testing.T) {
+
+	assert := assert.New(t)
+
+	tdir := t.TempDir()
+	file := filepath.Join(tdir, "binsanity.go")
+	args := []string{
+		"appname",
+		"--package=main",
+		"--module=biztos.com/example",
+		"--output=" + file,
+		"--dry-run",
+		ExampleAssetDir,
+	}
+
+	exit_code := 0
+	binsanity.ExitFunc = func(c int) { exit_code = c }
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	binsanity.OutWriter = stdout
+	binsanity.ErrWriter = stderr
+	defer RestoreDefaults()
+
+	binsanity.RunApp(args)
+	assert.Equal(0, exit_code, "exit 0")
+	assert.Regexp(regexp.MustCompile(
+		`^NAME +SIZE +STORED +RATIO +CODEC +SHA256\n`+
+			`bar +12 +\d+ +\d+\.\d% +gzip +45aab2edbbcaeaea`), stdout.String(), "table")
+	assert.True(strings.HasSuffix(stdout.String(),
+		"\nfiles: 4, bytes: 58, saved: 52\n"), "summary")
+	assert.Equal("", stderr.String(), "stderr")
+	assert.NoFileExists(file)
+
+	// And as JSON.
+	stdout.Reset()
+	args = append(args[:5], "--json", ExampleAssetDir)
+	binsanity.RunApp(args)
+	assert.Equal(0, exit_code, "exit 0")
+	res := &binsanity.Result{}
+	if assert.Nil(json.Unmarshal(stdout.Bytes(), res), "json") {
+		assert.Equal(4, res.Files, "files")
+		assert.Equal(52, res.Saved, "saved")
+		assert.Equal("baz/foo", res.Assets[2].Name, "name")
+		assert.Equal(12, res.Assets[2].Size, "size")
+	}
+	assert.NoFileExists(file)
+
+	// Never quietly passes a check.
+	stdout.Reset()
+	args = append(args[:5], "--check", ExampleAssetDir)
+	binsanity.RunApp(args)
+	assert.Equal(1, exit_code, "exit 1")
+	assert.Equal("Check and dry run can't be combined.\n", stderr.String(), "stderr")
+
+}
+
+func TestRunAppCheckJSON(t *testing.T) {
+
+	assert := assert.New(t)
+
+	tdir := t.TempDir()
+	args := []string{
+		"appname",
+		"--package=main",
+		"--module=biztos.com/example",
+		"--output=" + filepath.Join(tdir, "binsanity.go"),
+		"--check",
+		"--json",
+		ExampleAssetDir,
+	}
+
+	exit_code := 0
+	binsanity.ExitFunc = func(c int) { exit_code = c }
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	binsanity.OutWriter = stdout
+	binsanity.ErrWriter = stderr
+	defer RestoreDefaults()
+
+	binsanity.RunApp(args)
+	assert.Equal(1, exit_code, "exit 1")
+	res := &binsanity.Result{}
+	if assert.Nil(json.Unmarshal(stdout.Bytes(), res), "json") {
+		assert.True(res.Stale, "stale")
+		assert.Equal([]string{"bar", "baz/bat/bloopf", "baz/foo", "foo"},
+			res.Changes.Added, "added")
+	}
+	assert.Equal("Generated files are out of date.\n", stderr.String(), "stderr")
+
+}
+
+func TestRunAppJSON(t *testing.T) {
+
+	assert := assert.New(t)
+
+	tdir := t.TempDir()
+	file := filepath.Join(tdir, "binsanity.go")
+	args := []string{
+		"appname",
+		"--package=main",
+		"--module=biztos.com/example",
+		"--output=" + file,
+		"--json",
+		ExampleAssetDir,
+	}
+
+	exit_code := 0
+	binsanity.ExitFunc = func(c int) { exit_code = c }
+	stdout := &bytes.Buffer{}
+	binsanity.OutWriter = stdout
+	defer RestoreDefaults()
+
+	binsanity.RunApp(args)
+	assert.Equal(0, exit_code, "exit 0")
+	res := &binsanity.Result{}
+	if assert.Nil(json.Unmarshal(stdout.Bytes(), res), "json") {
+		assert.Equal(4, res.Files, "files")
+		assert.Equal(58, res.Bytes, "bytes")
+		assert.True(res.Stored > 0, "stored")
+		assert.True(res.Source > 0, "source")
+		assert.Equal([]string{}, res.Skipped, "skipped")
+		assert.Equal(filepath.Join(ExampleAssetDir, "bar"), res.Assets[0].Path, "path")
+		assert.Equal("gzip", res.Assets[0].Codec, "codec")
+	}
+	assert.FileExists(file)
+
+}
+
+func TestRunAppDotDir(t *testing.T) {
+
+	assert := assert.New(t)
+
+	// Dirs named like subcommands, as the usage suggests.
+	commands := []string{"init", "extract", "inspect", "diff", "verify", "migrate",
+		"regen", "help"}
+	tdir := t.TempDir()
+	for _, name := range commands {
+		WriteTree(t, tdir, map[string]string{name + "/x": name})
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(tdir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	exit_code := 0
+	binsanity.ExitFunc = func(c int) { exit_code = c }
+	stdout := &bytes.Buffer{}
+	binsanity.OutWriter = stdout
+	binsanity.ErrWriter = &bytes.Buffer{}
+	defer RestoreDefaults()
+
+	for _, name := range commands {
+		stdout.Reset()
+		binsanity.RunApp([]string{"appname", "--package=foo", "--module=example.com/foo",
+			"--dry-run", "--json", "./" + name})
+		assert.Equal(0, exit_code, "exit 0 for %s", name)
+		res := &binsanity.Result{}
+		if assert.Nil(json.Unmarshal(stdout.Bytes(), res), "json for %s", name) &&
+			assert.Equal(1, len(res.Assets), "assets for %s", name) {
+			assert.Equal(filepath.Join(name, "x"), res.Assets[0].Path, "path for %s", name)
+		}
+	}
+
+}
@@ -0,0 +1,94 @@
+// binscheck.go -- binsanity comparison of generated assets.
+
+package binsanity
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// AssetChanges lists the assets added, removed and changed (by content)
+// between two sets of assets.  Each list is sorted.
+type AssetChanges struct {
+	Added   []string `json:"added"`
+	Removed []string `json:"removed"`
+	Changed []string `json:"changed"`
+}
+
+// CompareSums returns the changes from the old to the new set of assets,
+// each given as a map of names to content sums.
+func CompareSums(old, new map[string]string) *AssetChanges {
+
+	c := &AssetChanges{
+		Added:   []string{},
+		Removed: []string{},
+		Changed: []string{},
+	}
+	for name, sum := range new {
+		old_sum, found := old[name]
+		if !found {
+			c.Added = append(c.Added, name)
+		} else if old_sum != sum {
+			c.Changed = append(c.Changed, name)
+		}
+	}
+	for name := range old {
+		if _, found := new[name]; !found {
+			c.Removed = append(c.Removed, name)
+		}
+	}
+	sort.Strings(c.Added)
+	sort.Strings(c.Removed)
+	sort.Strings(c.Changed)
+	return c
+
+}
+
+// checkChanges returns the changes between the assets in the existing code
+// file, if any, and those about to be generated.  A code file that can't be
+// read counts as having no assets.
+func checkChanges(file string, gen *GenData) *AssetChanges {
+
+	old := map[string]string{}
+	if existing, err := ReadGenerated(file); err == nil {
+		if sums, err := existing.Sums(); err == nil {
+			old = sums
+		}
+	}
+	new := map[string]string{}
+	if !gen.AssetsEmpty {
+		for idx, name := range gen.Names {
+			new[name] = gen.DataSums[idx]
+		}
+	}
+	return CompareSums(old, new)
+
+}
+
+// Empty returns true if there are no changes.
+func (c *AssetChanges) Empty() bool {
+	return len(c.Added)+len(c.Removed)+len(c.Changed) == 0
+}
+
+// String returns a short summary of the changes: the counts on one line,
+// followed by a line for each asset prefixed with "+" if added, "-" if
+// removed, or "~" if changed.
+func (c *AssetChanges) String() string {
+
+	lines := []string{
+		fmt.Sprintf("added: %d, removed: %d, changed: %d",
+			len(c.Added), len(c.Removed), len(c.Changed)),
+	}
+	for _, name := range c.Added {
+		lines = append(lines, "+ "+name)
+	}
+	for _, name := range c.Removed {
+		lines = append(lines, "- "+name)
+	}
+	for _, name := range c.Changed {
+		lines = append(lines, "~ "+name)
+	}
+	return strings.Join(lines, "\n")
+
+}
@@ -0,0 +1,168 @@
+// binscheck_test.go - tests for stuff in binscheck.go, and checking
+package binsanity_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/biztos/binsanity"
+)
+
+func TestCompareSums(t *testing.T) {
+
+	assert := assert.New(t)
+
+	old := map[string]string{"a": "1", "b": "2", "c": "3", "d": "4"}
+	new := map[string]string{"a": "1", "c": "33", "b": "22", "e": "5", "f": "6"}
+	c := binsanity.CompareSums(old, new)
+	assert.Equal([]string{"e", "f"}, c.Added)
+	assert.Equal([]string{"d"}, c.Removed)
+	assert.Equal([]string{"b", "c"}, c.Changed)
+	assert.False(c.Empty())
+	assert.Equal("added: 2, removed: 1, changed: 2\n+ e\n+ f\n- d\n~ b\n~ c", c.String())
+
+	c = binsanity.CompareSums(old, old)
+	assert.True(c.Empty())
+	assert.Equal("added: 0, removed: 0, changed: 0", c.String())
+
+}
+
+func TestProcessCheck(t *testing.T) {
+
+	assert := assert.New(t)
+
+	tdir := t.TempDir()
+	adir := filepath.Join(tdir, "assets")
+	WriteTree(t, adir, map[string]string{"a": "a", "b": "b", "c/d": "d"})
+	odir := filepath.Join(tdir, "out")
+	if err := os.Mkdir(odir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &binsanity.Config{
+		Dir:     adir,
+		File:    filepath.Join(odir, "binsanity.go"),
+		Package: "foo",
+		Module:  "example.com/foo",
+		Check:   true,
+	}
+	check := func() *binsanity.Result {
+		before := readAll(t, odir)
+		res, err := binsanity.Process(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(before, readAll(t, odir), "nothing written")
+		return res
+	}
+
+	// Nothing there yet.
+	res := check()
+	assert.True(res.Stale, "stale when missing")
+	assert.Equal([]string{"a", "b", "c/d"}, res.Changes.Added)
+
+	// Up to date.
+	cfg.Check = false
+	if _, err := binsanity.Process(cfg); err != nil {
+		t.Fatal(err)
+	}
+	cfg.Check = true
+	res = check()
+	assert.False(res.Stale, "not stale")
+	assert.True(res.Changes.Empty(), "no changes")
+	assert.Equal(3, res.Files)
+
+	// Changes in assets.
+	WriteTree(t, adir, map[string]string{"b": "bb", "e": "e"})
+	if err := os.Remove(filepath.Join(adir, "a")); err != nil {
+		t.Fatal(err)
+	}
+	res = check()
+	assert.True(res.Stale, "stale after changes")
+	assert.Equal("added: 1, removed: 1, changed: 1\n+ e\n- a\n~ b",
+		res.Changes.String())
+
+	// Changes in the generated code only, like a new package name, or an
+	// edit to either file.
+	WriteTree(t, adir, map[string]string{"b": "b", "a": "a"})
+	if err := os.Remove(filepath.Join(adir, "e")); err != nil {
+		t.Fatal(err)
+	}
+	assert.False(check().Stale, "back to the start")
+	cfg.Package = "bar"
+	res = check()
+	assert.True(res.Stale, "stale with new package")
+	assert.True(res.Changes.Empty(), "but no asset changes")
+	cfg.Package = "foo"
+	tfile := filepath.Join(odir, "binsanity_test.go")
+	b, err := os.ReadFile(tfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, edit := range [][]byte{b[:len(b)-1], append(b, '\n')} {
+		WriteTree(t, odir, map[string]string{"binsanity_test.go": string(edit)})
+		assert.True(check().Stale, "stale with edited test file")
+	}
+	WriteTree(t, odir, map[string]string{"binsanity_test.go": string(b)})
+	assert.False(check().Stale, "fixed again")
+
+	// Stale companion file, or split where it wasn't before.
+	WriteTree(t, odir, map[string]string{"binsanity_data_001.go": "stale"})
+	assert.True(check().Stale, "stale companion")
+	if err := os.Remove(filepath.Join(odir, "binsanity_data_001.go")); err != nil {
+		t.Fatal(err)
+	}
+	cfg.SplitSize = 100
+	assert.True(check().Stale, "now splitting")
+
+}
+
+func TestRunAppCheck(t *testing.T) {
+
+	assert := assert.New(t)
+
+	tdir := t.TempDir()
+	file := filepath.Join(tdir, "binsanity.go")
+	args := []string{
+		"appname",
+		"--package=main",
+		"--module=biztos.com/example",
+		"--output=" + file,
+		"--check",
+		ExampleAssetDir,
+	}
+
+	exit_code := 0
+	binsanity.ExitFunc = func(c int) { exit_code = c }
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	binsanity.OutWriter = stdout
+	binsanity.ErrWriter = stderr
+	defer RestoreDefaults()
+
+	binsanity.RunApp(args)
+	assert.Equal(1, exit_code, "exit 1")
+	assert.Equal(`files: 4, bytes: 58, saved: 52
+added: 4, removed: 0, changed: 0
++ bar
++ baz/bat/bloopf
++ baz/foo
++ foo
+`, stdout.String(), "stdout")
+	assert.Equal("Generated files are out of date.\n", stderr.String(), "stderr")
+	assert.NoFileExists(file)
+
+	// Now with the real thing.
+	exit_code = 0
+	stdout.Reset()
+	stderr.Reset()
+	args[3] = "--output=" + filepath.Join(ExampleDir, "binsanity.go")
+	binsanity.RunApp(args)
+	assert.Equal(0, exit_code, "exit 0")
+	assert.Equal("files: 4, bytes: 58, saved: 52\n", stdout.String(), "stdout")
+	assert.Equal("", stderr.String(), "stderr")
+
+}
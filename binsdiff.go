@@ -0,0 +1,332 @@
+// binsdiff.go -- binsanity differences between generated files and assets.
+
+package binsanity
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// DiffContext is the number of unchanged lines shown around each change in
+// a unified diff.
+var DiffContext = 3
+
+// AssetDiff holds the differences between the assets in a generated code
+// file and those that would be generated from a directory now.
+type AssetDiff struct {
+	*AssetChanges
+	gen   *Generated
+	paths map[string]string // asset name -> file in the directory
+}
+
+// Diff compares the assets in the code file generated by binsanity at file
+// with those Process would generate from dir now, by content.  The include
+// and exclude patterns recorded in the file, if any, are applied to dir.
+func Diff(file, dir string) (*AssetDiff, error) {
+
+	gen, err := ReadGenerated(file)
+	if err != nil {
+		return nil, err
+	}
+	old, err := gen.Sums()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+
+	cfg := &Config{}
+	rec, err := ReadConfig(file)
+	if err == nil {
+		cfg = rec.Config(file)
+	} else if !errors.Is(err, ErrNoConfig) {
+		return nil, err
+	}
+	cfg.Dir = dir
+	cfg.File = file
+	cfg.DryRun = true
+	res, err := Process(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &AssetDiff{gen: gen, paths: map[string]string{}}
+	new := map[string]string{}
+	for _, a := range res.Assets {
+		new[a.Name] = a.Sum
+		d.paths[a.Name] = a.Path
+	}
+	d.AssetChanges = CompareSums(old, new)
+	return d, nil
+
+}
+
+// Unified returns a unified diff of the content of each changed asset, from
+// the generated file to the directory, or a note that they differ for those
+// that aren't text.
+func (d *AssetDiff) Unified() (string, error) {
+
+	parts := []string{}
+	for _, name := range d.Changed {
+		old, err := d.gen.Asset(name)
+		if err != nil {
+			return "", err
+		}
+		new, err := os.ReadFile(d.paths[name])
+		if err != nil {
+			return "", err
+		}
+		if !isText(old) || !isText(new) {
+			parts = append(parts, fmt.Sprintf("Binary asset %s differs\n", name))
+			continue
+		}
+		parts = append(parts, UnifiedDiff(string(old), string(new),
+			"a/"+name, "b/"+name))
+	}
+	return strings.Join(parts, ""), nil
+
+}
+
+// isText returns true if b looks like text: valid UTF-8 without NULs.
+func isText(b []byte) bool {
+	return utf8.Valid(b) && bytes.IndexByte(b, 0) < 0
+}
+
+// diffEdit is one line of a line diff: ' ' for unchanged, '-' for removed
+// or '+' for added.
+type diffEdit struct {
+	op   byte
+	line string
+}
+
+// splitLines splits s into lines, each keeping its newline if it has one.
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines returns the shortest edit script from a to b, using the
+// linear-space version of Myers' algorithm, so memory use is proportional to
+// the number of lines however many of them changed.  In each run of changes
+// the removed lines come before the added ones, as with GNU diff.
+func diffLines(a, b []string) []diffEdit {
+
+	edits := diffRange(make([]diffEdit, 0, len(a)+len(b)), a, b)
+
+	// Put the removals first in each run of changes.
+	for start := 0; start < len(edits); start++ {
+		if edits[start].op == ' ' {
+			continue
+		}
+		end := start
+		for end < len(edits) && edits[end].op != ' ' {
+			end++
+		}
+		run := edits[start:end]
+		sort.SliceStable(run, func(i, j int) bool {
+			return run[i].op == '-' && run[j].op == '+'
+		})
+		start = end
+	}
+	return edits
+
+}
+
+// diffRange appends the edits from a to b to edits, splitting the problem at
+// a point on a shortest path found by diffBisect.
+func diffRange(edits []diffEdit, a, b []string) []diffEdit {
+
+	// Lines in common at either end are simply kept.
+	for len(a) > 0 && len(b) > 0 && a[0] == b[0] {
+		edits = append(edits, diffEdit{' ', a[0]})
+		a, b = a[1:], b[1:]
+	}
+	common := 0
+	for common < len(a) && common < len(b) &&
+		a[len(a)-1-common] == b[len(b)-1-common] {
+		common++
+	}
+	tail := a[len(a)-common:]
+	a, b = a[:len(a)-common], b[:len(b)-common]
+
+	x, y, found := -1, -1, false
+	if len(a) > 0 && len(b) > 0 {
+		x, y, found = diffBisect(a, b)
+	}
+	if found {
+		edits = diffRange(edits, a[:x], b[:y])
+		edits = diffRange(edits, a[x:], b[y:])
+	} else {
+		for _, line := range a {
+			edits = append(edits, diffEdit{'-', line})
+		}
+		for _, line := range b {
+			edits = append(edits, diffEdit{'+', line})
+		}
+	}
+	for _, line := range tail {
+		edits = append(edits, diffEdit{' ', line})
+	}
+	return edits
+
+}
+
+// diffBisect finds the middle snake of a shortest edit script from a to b,
+// searching forward from the start and backward from the end at once, and
+// returns the point where the searches meet.  If a and b have nothing in
+// common, found is false.  Both must be non-empty and differ at each end.
+func diffBisect(a, b []string) (x, y int, found bool) {
+
+	n, m := len(a), len(b)
+	max_d := (n + m + 1) / 2
+	off := max_d + 1
+	fwd := make([]int, 2*off+1) // furthest x on each diagonal from the start
+	rev := make([]int, 2*off+1) // furthest distance back from the end
+	for i := range fwd {
+		fwd[i], rev[i] = -1, -1
+	}
+	fwd[off+1], rev[off+1] = 0, 0
+	delta := n - m
+	front := delta%2 != 0
+
+	// Diagonals that ran off the edge are left out of later rounds.
+	k1start, k1end, k2start, k2end := 0, 0, 0, 0
+	for d := 0; d < max_d; d++ {
+
+		for k1 := -d + k1start; k1 <= d-k1end; k1 += 2 {
+			i := off + k1
+			var x1 int
+			if k1 == -d || (k1 != d && fwd[i-1] < fwd[i+1]) {
+				x1 = fwd[i+1]
+			} else {
+				x1 = fwd[i-1] + 1
+			}
+			y1 := x1 - k1
+			for x1 < n && y1 < m && a[x1] == b[y1] {
+				x1++
+				y1++
+			}
+			fwd[i] = x1
+			if x1 > n {
+				k1end += 2
+			} else if y1 > m {
+				k1start += 2
+			} else if front {
+				j := off + delta - k1
+				if j >= 0 && j < len(rev) && rev[j] != -1 && x1 >= n-rev[j] {
+					return x1, y1, true
+				}
+			}
+		}
+
+		for k2 := -d + k2start; k2 <= d-k2end; k2 += 2 {
+			i := off + k2
+			var x2 int
+			if k2 == -d || (k2 != d && rev[i-1] < rev[i+1]) {
+				x2 = rev[i+1]
+			} else {
+				x2 = rev[i-1] + 1
+			}
+			y2 := x2 - k2
+			for x2 < n && y2 < m && a[n-x2-1] == b[m-y2-1] {
+				x2++
+				y2++
+			}
+			rev[i] = x2
+			if x2 > n {
+				k2end += 2
+			} else if y2 > m {
+				k2start += 2
+			} else if !front {
+				j := off + delta - k2
+				if j >= 0 && j < len(fwd) && fwd[j] != -1 && fwd[j] >= n-x2 {
+					return fwd[j], fwd[j] - (j - off), true
+				}
+			}
+		}
+
+	}
+	return 0, 0, false
+
+}
+
+// UnifiedDiff returns a unified diff of the lines of a and b, labeled with
+// a_name and b_name, with DiffContext lines of context.  It is empty if
+// they are the same.
+func UnifiedDiff(a, b, a_name, b_name string) string {
+
+	edits := diffLines(splitLines(a), splitLines(b))
+
+	// Find the changes, and group those close enough to share context.
+	type span struct{ start, end int }
+	hunks := []span{}
+	for idx, e := range edits {
+		if e.op == ' ' {
+			continue
+		}
+		start := idx - DiffContext
+		if start < 0 {
+			start = 0
+		}
+		end := idx + DiffContext + 1
+		if end > len(edits) {
+			end = len(edits)
+		}
+		if len(hunks) > 0 && start <= hunks[len(hunks)-1].end {
+			hunks[len(hunks)-1].end = end
+		} else {
+			hunks = append(hunks, span{start, end})
+		}
+	}
+	if len(hunks) == 0 {
+		return ""
+	}
+
+	// Line numbers before each edit.
+	a_line := make([]int, len(edits)+1)
+	b_line := make([]int, len(edits)+1)
+	for idx, e := range edits {
+		a_line[idx+1], b_line[idx+1] = a_line[idx], b_line[idx]
+		if e.op != '+' {
+			a_line[idx+1]++
+		}
+		if e.op != '-' {
+			b_line[idx+1]++
+		}
+	}
+
+	sb := &strings.Builder{}
+	fmt.Fprintf(sb, "--- %s\n+++ %s\n", a_name, b_name)
+	for _, h := range hunks {
+		fmt.Fprintf(sb, "@@ -%s +%s @@\n",
+			hunkRange(a_line[h.start], a_line[h.end]-a_line[h.start]),
+			hunkRange(b_line[h.start], b_line[h.end]-b_line[h.start]))
+		for _, e := range edits[h.start:h.end] {
+			sb.WriteByte(e.op)
+			sb.WriteString(e.line)
+			if !strings.HasSuffix(e.line, "\n") {
+				sb.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+	}
+	return sb.String()
+
+}
+
+// hunkRange returns the range of count lines after line start (from zero)
+// as shown in a unified diff hunk header.
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
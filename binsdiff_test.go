@@ -0,0 +1,272 @@
+// binsdiff_test.go - tests for stuff in binsdiff.go
+package binsanity_test
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/biztos/binsanity"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+
+	assert := assert.New(t)
+
+	assert.Equal("", binsanity.UnifiedDiff("a\nb\n", "a\nb\n", "x", "y"), "same")
+	assert.Equal("", binsanity.UnifiedDiff("", "", "x", "y"), "empty")
+
+	assert.Equal(`--- x
++++ y
+@@ -1,3 +1,3 @@
+ a
+-b
++B
+ c
+`, binsanity.UnifiedDiff("a\nb\nc\n", "a\nB\nc\n", "x", "y"), "changed line")
+
+	assert.Equal(`--- x
++++ y
+@@ -0,0 +1,2 @@
++a
++b
+`, binsanity.UnifiedDiff("", "a\nb\n", "x", "y"), "from empty")
+
+	assert.Equal(`--- x
++++ y
+@@ -1 +0,0 @@
+-a
+`, binsanity.UnifiedDiff("a\n", "", "x", "y"), "to empty")
+
+	assert.Equal(`--- x
++++ y
+@@ -1,2 +1,2 @@
+ a
+-b
+\ No newline at end of file
++b
+`, binsanity.UnifiedDiff("a\nb", "a\nb\n", "x", "y"), "no newline")
+
+	// Changes far enough apart get separate hunks, close ones share.
+	a := strings.Repeat("x\n", 20)
+	b := "y\n" + strings.Repeat("x\n", 9) + "y\n" + strings.Repeat("x\n", 9) + "z\n"
+	assert.Equal(`--- x
++++ y
+@@ -1,3 +1,4 @@
++y
+ x
+ x
+ x
+@@ -7,6 +8,7 @@
+ x
+ x
+ x
++y
+ x
+ x
+ x
+@@ -16,5 +18,4 @@
+ x
+ x
+ x
+-x
+-x
++z
+`, binsanity.UnifiedDiff(a, b, "x", "y"), "hunks")
+
+	a = "1\n2\n3\n4\n5\n6\n"
+	b = "1\n2\nthree\n4\nfive\n6\n"
+	assert.Equal(`--- x
++++ y
+@@ -1,6 +1,6 @@
+ 1
+ 2
+-3
++three
+ 4
+-5
++five
+ 6
+`, binsanity.UnifiedDiff(a, b, "x", "y"), "shared hunk")
+
+}
+
+func TestUnifiedDiffLarge(t *testing.T) {
+
+	assert := assert.New(t)
+
+	// Every line changed, which is the worst case for the search.
+	a := &strings.Builder{}
+	b := &strings.Builder{}
+	for i := 0; i < 5000; i++ {
+		fmt.Fprintf(a, "old %d\n", i)
+		fmt.Fprintf(b, "new %d\n", i)
+	}
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	diff := binsanity.UnifiedDiff(a.String(), b.String(), "x", "y")
+	runtime.ReadMemStats(&after)
+
+	lines := strings.Split(diff, "\n")
+	assert.Equal(3+5000+5000+1, len(lines), "all lines")
+	assert.Equal("@@ -1,5000 +1,5000 @@", lines[2], "one hunk")
+	assert.Equal("-old 0", lines[3], "removed first")
+	assert.Equal("+new 0", lines[5003], "then added")
+	assert.Less(after.TotalAlloc-before.TotalAlloc, uint64(16<<20), "memory")
+
+}
+
+func TestDiff(t *testing.T) {
+
+	assert := assert.New(t)
+
+	// No changes for the example as generated.
+	file := filepath.Join(ExampleDir, "binsanity.go")
+	d, err := binsanity.Diff(file, ExampleAssetDir)
+	if !assert.Nil(err, "no error") {
+		return
+	}
+	assert.True(d.Empty(), "no changes")
+	text, err := d.Unified()
+	assert.Nil(err, "no error")
+	assert.Equal("", text, "no diff")
+
+	tdir := t.TempDir()
+	CopyTree(t, ExampleAssetDir, tdir)
+	WriteTree(t, tdir, map[string]string{
+		"bar":     "bar is not bar\n\n",
+		"baz/foo": "foo\x00",
+		"new":     "new",
+	})
+	if err := os.Remove(filepath.Join(tdir, "foo")); err != nil {
+		t.Fatal(err)
+	}
+	d, err = binsanity.Diff(file, tdir)
+	if !assert.Nil(err, "no error") {
+		return
+	}
+	assert.Equal(&binsanity.AssetChanges{
+		Added:   []string{"new"},
+		Removed: []string{"foo"},
+		Changed: []string{"bar", "baz/foo"},
+	}, d.AssetChanges, "changes")
+	text, err = d.Unified()
+	assert.Nil(err, "no error")
+	assert.Equal(`--- a/bar
++++ b/bar
+@@ -1,2 +1,2 @@
+-bar is bar
++bar is not bar
+ 
+Binary asset baz/foo differs
+`, text, "unified")
+
+}
+
+func TestDiffFilters(t *testing.T) {
+
+	assert := assert.New(t)
+
+	// Recorded patterns are applied to the dir.
+	tdir := t.TempDir()
+	CopyTree(t, ExampleAssetDir, filepath.Join(tdir, "assets"))
+	file := filepath.Join(tdir, "binsanity.go")
+	_, err := binsanity.Process(&binsanity.Config{
+		Dir:     filepath.Join(tdir, "assets"),
+		File:    file,
+		Package: "main",
+		Module:  "x",
+		Exclude: []string{"baz"},
+	})
+	if !assert.Nil(err, "no error") {
+		return
+	}
+	WriteTree(t, tdir, map[string]string{"assets/baz/new": "new"})
+	d, err := binsanity.Diff(file, filepath.Join(tdir, "assets"))
+	if assert.Nil(err, "no error") {
+		assert.True(d.Empty(), "excluded")
+	}
+
+}
+
+func TestDiffErrors(t *testing.T) {
+
+	assert := assert.New(t)
+
+	_, err := binsanity.Diff(filepath.Join(ExampleDir, "nope.go"), ExampleAssetDir)
+	assert.Error(err, "missing file")
+
+	_, err = binsanity.Diff(filepath.Join(ExampleDir, "binsanity.go"), NonesuchDir)
+	assert.Error(err, "missing dir")
+
+	tdir := t.TempDir()
+	CopyTree(t, ExampleDir, tdir)
+	file := filepath.Join(tdir, "binsanity.go")
+	src := readAll(t, tdir)["binsanity.go"]
+	edit := func(old, new string) {
+		WriteTree(t, tdir, map[string]string{"binsanity.go": strings.Replace(src, old, new, 1)})
+	}
+	edit(`"H4sI`, `"!!!!`)
+	_, err = binsanity.Diff(file, ExampleAssetDir)
+	assert.ErrorContains(err, file+": ", "encoding")
+	edit(`"version":`, `"version"`)
+	_, err = binsanity.Diff(file, ExampleAssetDir)
+	assert.ErrorContains(err, file+": config: ", "config")
+
+	// Unified reads the changed files again, which might be gone by then.
+	adir := filepath.Join(tdir, "assets")
+	WriteTree(t, adir, map[string]string{"bar": "changed"})
+	d, err := binsanity.Diff(filepath.Join(ExampleDir, "binsanity.go"), adir)
+	if !assert.Nil(err, "no error") {
+		return
+	}
+	if err := os.Remove(filepath.Join(adir, "bar")); err != nil {
+		t.Fatal(err)
+	}
+	_, err = d.Unified()
+	assert.Error(err, "vanished")
+
+}
+
+func TestRunAppDiff(t *testing.T) {
+
+	assert := assert.New(t)
+
+	exit_code := 0
+	binsanity.ExitFunc = func(c int) { exit_code = c }
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	binsanity.OutWriter = stdout
+	binsanity.ErrWriter = stderr
+	defer RestoreDefaults()
+
+	tdir := t.TempDir()
+	CopyTree(t, ExampleAssetDir, tdir)
+	WriteTree(t, tdir, map[string]string{"bar": "bar is bar\n"})
+	file := filepath.Join(ExampleDir, "binsanity.go")
+
+	binsanity.RunApp([]string{"appname", "diff", file, tdir})
+	assert.Equal(0, exit_code, "exit 0")
+	assert.Equal("added: 0, removed: 0, changed: 1\n~ bar\n", stdout.String(), "stdout")
+
+	stdout.Reset()
+	binsanity.RunApp([]string{"appname", "diff", "-u", file, tdir})
+	assert.Equal(0, exit_code, "exit 0")
+	assert.Equal("added: 0, removed: 0, changed: 1\n~ bar\n"+
+		"--- a/bar\n+++ b/bar\n@@ -1,2 +1 @@\n bar is bar\n-\n", stdout.String(), "unified")
+
+	binsanity.RunApp([]string{"appname", "diff", file})
+	assert.Equal(1, exit_code, "exit 1")
+	assert.Equal("Two args required: FILE.go DIR\n", stderr.String(), "stderr")
+
+	exit_code = 0
+	binsanity.RunApp([]string{"appname", "diff", file, NonesuchDir})
+	assert.Equal(1, exit_code, "exit 1")
+
+}
@@ -0,0 +1,36 @@
+// binserr.go -- binsanity processing errors.
+
+package binsanity
+
+import (
+	"fmt"
+)
+
+// The stages of Process, as named in a ProcessError.
+const (
+	StageScan     = "scan"     // finding the asset files
+	StageRead     = "read"     // reading an asset file
+	StageCompress = "compress" // compressing and encoding an asset file
+	StageRender   = "render"   // parsing or executing a template
+	StageWrite    = "write"    // writing or replacing an output file
+)
+
+// ProcessError is returned by Process for any failure after its arguments
+// have been checked.  It names the stage that failed and the file involved:
+// the asset dir or file for scan, read and compress; the template asset for
+// a template that won't parse; and the output file otherwise.
+type ProcessError struct {
+	Stage string
+	File  string
+	Err   error
+}
+
+// Error returns the stage, file and underlying error as a single string.
+func (e *ProcessError) Error() string {
+	return fmt.Sprintf("%s %s: %v", e.Stage, e.File, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *ProcessError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,206 @@
+// binserr_test.go - tests for stuff in binserr.go, and the error paths of
+// Process that use it.
+package binsanity_test
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/biztos/binsanity"
+)
+
+func TestProcessErrorString(t *testing.T) {
+
+	assert := assert.New(t)
+
+	inner := errors.New("oops")
+	err := &binsanity.ProcessError{
+		Stage: binsanity.StageRead,
+		File:  "foo.txt",
+		Err:   inner,
+	}
+	assert.Equal("read foo.txt: oops", err.Error())
+	assert.ErrorIs(err, inner)
+
+}
+
+// failWriter fails every write.
+type failWriter struct{}
+
+func (fw *failWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+func (fw *failWriter) Close() error {
+	return errors.New("close failed")
+}
+
+// closeFailWriter fails only on close.
+type closeFailWriter struct{ io.Writer }
+
+func (cw *closeFailWriter) Close() error {
+	return errors.New("close failed")
+}
+
+// processErr runs Process and returns its error as a ProcessError, failing
+// the test if it isn't one.
+func processErr(t *testing.T, cfg *binsanity.Config) *binsanity.ProcessError {
+	_, err := binsanity.Process(cfg)
+	var perr *binsanity.ProcessError
+	if !errors.As(err, &perr) {
+		t.Fatalf("not a ProcessError: %#v", err)
+	}
+	return perr
+}
+
+func TestProcessErrorStages(t *testing.T) {
+
+	tdir := t.TempDir()
+	adir := filepath.Join(tdir, "assets")
+	WriteTree(t, adir, map[string]string{"foo": "foo", "bar": "bar"})
+	file := filepath.Join(tdir, "binsanity.go")
+	newcfg := func() *binsanity.Config {
+		return &binsanity.Config{
+			Dir:     adir,
+			File:    file,
+			Package: "foo",
+			Module:  "example.com/foo",
+		}
+	}
+
+	tests := []struct {
+		name  string
+		setup func(cfg *binsanity.Config)
+		stage string
+		file  string
+		msg   string
+	}{
+		{
+			name:  "no asset dir",
+			setup: func(cfg *binsanity.Config) { cfg.Dir = NonesuchDir },
+			stage: binsanity.StageScan,
+			file:  NonesuchDir,
+			msg:   "Asset dir: ",
+		},
+		{
+			name:  "asset dir not dir",
+			setup: func(cfg *binsanity.Config) { cfg.Dir = filepath.Join(adir, "foo") },
+			stage: binsanity.StageScan,
+			file:  filepath.Join(adir, "foo"),
+			msg:   "Not a directory",
+		},
+		{
+			name: "open fails",
+			setup: func(cfg *binsanity.Config) {
+				binsanity.OsOpen = func(string) (*os.File, error) {
+					return nil, errors.New("open failed")
+				}
+			},
+			stage: binsanity.StageRead,
+			file:  filepath.Join(adir, "bar"),
+			msg:   "open failed",
+		},
+		{
+			name: "compress write fails",
+			setup: func(cfg *binsanity.Config) {
+				binsanity.NewCompressor = func(w io.Writer) io.WriteCloser {
+					return &failWriter{}
+				}
+			},
+			stage: binsanity.StageCompress,
+			file:  filepath.Join(adir, "bar"),
+			msg:   "write failed",
+		},
+		{
+			name: "compress close fails",
+			setup: func(cfg *binsanity.Config) {
+				binsanity.NewCompressor = func(w io.Writer) io.WriteCloser {
+					return &closeFailWriter{w}
+				}
+			},
+			stage: binsanity.StageCompress,
+			file:  filepath.Join(adir, "bar"),
+			msg:   "close failed",
+		},
+		{
+			name:  "code template parse",
+			setup: func(cfg *binsanity.Config) { binsanity.CodeTemplate = "{{" },
+			stage: binsanity.StageRender,
+			file:  "code.tmpl",
+			msg:   "unclosed action",
+		},
+		{
+			name:  "code template execute",
+			setup: func(cfg *binsanity.Config) { binsanity.CodeTemplate = "{{.Nope}}" },
+			stage: binsanity.StageRender,
+			file:  file,
+			msg:   "can't evaluate field Nope",
+		},
+		{
+			name:  "test template parse",
+			setup: func(cfg *binsanity.Config) { binsanity.TestTemplate = "{{" },
+			stage: binsanity.StageRender,
+			file:  "tests.tmpl",
+			msg:   "unclosed action",
+		},
+		{
+			name:  "test template execute",
+			setup: func(cfg *binsanity.Config) { binsanity.TestTemplate = "{{.Nope}}" },
+			stage: binsanity.StageRender,
+			file:  filepath.Join(tdir, "binsanity_test.go"),
+			msg:   "can't evaluate field Nope",
+		},
+		{
+			name: "data template parse",
+			setup: func(cfg *binsanity.Config) {
+				cfg.SplitSize = 1
+				binsanity.DataTemplate = "{{"
+			},
+			stage: binsanity.StageRender,
+			file:  "data.tmpl",
+			msg:   "unclosed action",
+		},
+		{
+			name: "data template execute",
+			setup: func(cfg *binsanity.Config) {
+				cfg.SplitSize = 1
+				binsanity.DataTemplate = "{{.Nope}}"
+			},
+			stage: binsanity.StageRender,
+			file:  filepath.Join(tdir, "binsanity_data_001.go"),
+			msg:   "can't evaluate field Nope",
+		},
+		{
+			name:  "no output dir",
+			setup: func(cfg *binsanity.Config) { cfg.File = filepath.Join(NonesuchDir, "x.go") },
+			stage: binsanity.StageWrite,
+			file:  filepath.Join(NonesuchDir, "x.go"),
+			msg:   "no such file",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+			defer RestoreDefaults()
+			cfg := newcfg()
+			tc.setup(cfg)
+			perr := processErr(t, cfg)
+			assert.Equal(tc.stage, perr.Stage, "stage")
+			assert.Equal(tc.file, perr.File, "file")
+			assert.ErrorContains(perr, tc.msg, "message")
+
+			// ...and nothing was written.
+			_, err := os.Stat(file)
+			assert.ErrorIs(err, os.ErrNotExist, "no code file")
+			files, _ := filepath.Glob(filepath.Join(tdir, "*.go"))
+			assert.Empty(files, "no files")
+		})
+	}
+
+}
@@ -0,0 +1,170 @@
+// binsextract.go -- binsanity recovery of assets from generated files.
+
+package binsanity
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ExtractResult is returned by Extract and records the number of files and
+// total bytes written, and whether their sums were verified.
+type ExtractResult struct {
+	Files    int  `json:"files"`
+	Bytes    int  `json:"bytes"`
+	Verified bool `json:"verified"`
+}
+
+// String returns the pretty-print version of ExtractResult.
+func (r *ExtractResult) String() string {
+	s := fmt.Sprintf("files: %d, bytes: %d", r.Files, r.Bytes)
+	if r.Verified {
+		return s + ", verified"
+	}
+	return s + ", not verified"
+}
+
+// TestFileName returns the name of the test file for the code file.
+func TestFileName(file string) string {
+	return file[:len(file)-len(filepath.Ext(file))] + "_test.go"
+}
+
+// ReadTestSums returns the sha256 sums of the assets, by name, from the test
+// file generated by binsanity at file.
+func ReadTestSums(file string) (map[string]string, error) {
+
+	vars, _, err := parseVars(file)
+	if err != nil {
+		return nil, err
+	}
+	names, err := stringList(vars["BinsanityAssetNames"])
+	if err != nil {
+		return nil, fmt.Errorf("%s: BinsanityAssetNames: %v", file, err)
+	}
+	sums, err := stringList(vars["BinsanityAssetSums"])
+	if err != nil {
+		return nil, fmt.Errorf("%s: BinsanityAssetSums: %v", file, err)
+	}
+
+	// With no assets there is still a sum, for the placeholder.
+	if len(names) > 0 && len(names) != len(sums) {
+		return nil, fmt.Errorf("%s: %d names but %d sums", file, len(names), len(sums))
+	}
+	res := map[string]string{}
+	for idx, name := range names {
+		res[name] = sums[idx]
+	}
+	return res, nil
+
+}
+
+// Extract writes the assets in the code file generated by binsanity at file
+// to outdir, recreating the asset dir it was generated from.  Names that are
+// not local paths, and so would escape outdir, are rejected before anything
+// is written, as are existing files unless force is set.
+//
+// If the test file generated with it exists, the content of every asset is
+// verified against the sums in it, and any mismatch is an error.  All of the
+// assets are verified before the first one is written.
+func Extract(file, outdir string, force bool) (*ExtractResult, error) {
+
+	gen, err := ReadGenerated(file)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkAssetPaths(file, outdir, gen.Names, force); err != nil {
+		return nil, err
+	}
+
+	var sums map[string]string
+	tfile := TestFileName(file)
+	if _, err := os.Stat(tfile); err == nil {
+		sums, err = ReadTestSums(tfile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Everything is decoded and verified before anything is written, so bad
+	// data leaves outdir alone.  It is decoded again to be written, so only
+	// one asset is held in memory at a time.
+	for idx, name := range gen.Names {
+		b, err := gen.Decode(gen.Index[idx])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s: %v", file, name, err)
+		}
+		if sums != nil {
+			want, found := sums[name]
+			if !found {
+				return nil, fmt.Errorf("%s: no sum for %s", tfile, name)
+			}
+			if sum := fmt.Sprintf("%x", sha256.Sum256(b)); sum != want {
+				return nil, fmt.Errorf("%s: sum mismatch for %s", tfile, name)
+			}
+		}
+	}
+
+	res := &ExtractResult{Verified: sums != nil}
+	for idx, name := range gen.Names {
+		b, _ := gen.Decode(gen.Index[idx]) // can't fail, it just worked
+		if err := writeAsset(outdir, name, b); err != nil {
+			return nil, err
+		}
+		res.Files++
+		res.Bytes += len(b)
+	}
+	return res, nil
+
+}
+
+// checkAssetPaths returns an error if any of the asset names from file is
+// not a local path, and so would escape outdir, or unless force is set, if
+// any of them exists there already.
+func checkAssetPaths(file, outdir string, names []string, force bool) error {
+
+	for _, name := range names {
+		if !isLocalName(name) {
+			return fmt.Errorf("%s: Unsafe asset name: %q", file, name)
+		}
+		if !force {
+			path := filepath.Join(outdir, filepath.FromSlash(name))
+			if _, err := os.Lstat(path); err == nil {
+				return fmt.Errorf("%s: File exists.", path)
+			}
+		}
+	}
+	return nil
+
+}
+
+// isLocalName returns true if the slash-separated name is clean and stays
+// within its directory on any OS: not absolute, not empty and with no ".."
+// elements.  Backslashes and colons are rejected outright, as on Windows
+// they could make a name absolute, give it a drive or escape by "..\".
+func isLocalName(name string) bool {
+
+	if strings.ContainsAny(name, "\\:") || filepath.VolumeName(filepath.FromSlash(name)) != "" {
+		return false
+	}
+	if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+		return false
+	}
+	return name == path.Clean(name) && name != "."
+
+}
+
+// writeAsset writes the content b of the named asset to its path under
+// outdir, creating any directories needed.
+func writeAsset(outdir, name string, b []byte) error {
+
+	path := filepath.Join(outdir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0644)
+
+}
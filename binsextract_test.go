@@ -0,0 +1,196 @@
+// binsextract_test.go - tests for stuff in binsextract.go
+package binsanity_test
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/biztos/binsanity"
+)
+
+func TestTestFileName(t *testing.T) {
+
+	assert := assert.New(t)
+
+	assert.Equal("binsanity_test.go", binsanity.TestFileName("binsanity.go"))
+	assert.Equal(filepath.Join("a", "b_test.go"),
+		binsanity.TestFileName(filepath.Join("a", "b.go")))
+
+}
+
+func TestExtract(t *testing.T) {
+
+	assert := assert.New(t)
+
+	want := ReadTree(t, ExampleAssetDir)
+	for _, dir := range []string{ExampleDir, LegacyDir} {
+		odir := t.TempDir()
+		res, err := binsanity.Extract(filepath.Join(dir, "binsanity.go"), odir, false)
+		if !assert.Nil(err, "no error") {
+			continue
+		}
+		if dir == LegacyDir {
+			// Older, without the duplicate.
+			delete(want, "baz/foo")
+		}
+		assert.Equal(want, ReadTree(t, odir), "assets %s", dir)
+		assert.Equal(len(want), res.Files, "files")
+		assert.True(res.Verified, "verified")
+	}
+
+}
+
+func TestExtractSplitUnverifiedEmpty(t *testing.T) {
+
+	assert := assert.New(t)
+
+	// Split, and without the test file.
+	tdir := t.TempDir()
+	cfg := &binsanity.Config{
+		Dir:       ExampleAssetDir,
+		File:      filepath.Join(tdir, "binsanity.go"),
+		Package:   "foo",
+		Module:    "example.com/foo",
+		SplitSize: 10,
+	}
+	if _, err := binsanity.Process(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(binsanity.TestFileName(cfg.File)); err != nil {
+		t.Fatal(err)
+	}
+	odir := t.TempDir()
+	res, err := binsanity.Extract(cfg.File, odir, false)
+	if assert.Nil(err, "no error") {
+		assert.Equal("files: 4, bytes: 58, not verified", res.String())
+		assert.Equal(ReadTree(t, ExampleAssetDir), ReadTree(t, odir), "assets")
+	}
+
+	// Empty.
+	edir := t.TempDir()
+	cfg.Dir = edir
+	cfg.SplitSize = 0
+	if _, err := binsanity.Process(cfg); err != nil {
+		t.Fatal(err)
+	}
+	res, err = binsanity.Extract(cfg.File, filepath.Join(edir, "out"), false)
+	if assert.Nil(err, "no error") {
+		assert.Equal("files: 0, bytes: 0, verified", res.String())
+	}
+	assert.NoDirExists(filepath.Join(edir, "out"))
+
+}
+
+func TestExtractErrors(t *testing.T) {
+
+	assert := assert.New(t)
+
+	// A copy of the example to mess with.
+	tdir := t.TempDir()
+	CopyTree(t, ExampleDir, tdir)
+	file := filepath.Join(tdir, "binsanity.go")
+	tfile := filepath.Join(tdir, "binsanity_test.go")
+	files := readAll(t, tdir)
+	edit := func(name, old, new string) {
+		WriteTree(t, tdir, map[string]string{
+			name: strings.Replace(files[name], old, new, 1),
+		})
+	}
+	extract := func(force bool) error {
+		_, err := binsanity.Extract(file, filepath.Join(tdir, "out"), force)
+		return err
+	}
+
+	// Existing files, unless forced.
+	WriteTree(t, tdir, map[string]string{"out/baz/foo": "old"})
+	assert.EqualError(extract(false),
+		filepath.Join(tdir, "out", "baz", "foo")+": File exists.", "exists")
+	assert.Equal("old", ReadTree(t, filepath.Join(tdir, "out"))["baz/foo"], "untouched")
+	assert.Nil(extract(true), "forced")
+
+	// Bad sums, with nothing written even for the good ones before.
+	edit("binsanity_test.go", "6112\",\n}", "0000\",\n}")
+	_, err := binsanity.Extract(file, filepath.Join(tdir, "out4"), false)
+	assert.EqualError(err, tfile+": sum mismatch for foo")
+	assert.NoDirExists(filepath.Join(tdir, "out4"), "nothing written")
+	edit("binsanity_test.go", `"45aab2`, `"00aab2`)
+	assert.EqualError(extract(true), tfile+": sum mismatch for bar")
+	edit("binsanity_test.go", `	"bar",`, `	"BAR",`)
+	assert.EqualError(extract(true), tfile+": no sum for bar")
+	edit("binsanity_test.go", `	"bar",`, ``)
+	assert.EqualError(extract(true), tfile+": 3 names but 4 sums")
+	edit("binsanity_test.go", `var BinsanityAssetSums`, `var NopeSums`)
+	assert.EqualError(extract(true), tfile+": BinsanityAssetSums: not found")
+	edit("binsanity_test.go", `var BinsanityAssetNames`, `var NopeNames`)
+	assert.EqualError(extract(true), tfile+": BinsanityAssetNames: not found")
+	edit("binsanity_test.go", `package`, `nope`)
+	assert.Error(extract(true), "test file unparsable")
+	WriteTree(t, tdir, map[string]string{"binsanity_test.go": files["binsanity_test.go"]})
+
+	// Names escaping the dir, here or on Windows.
+	for _, name := range []string{"../bar", "/bar", "baz/../../bar", "", "..", ".",
+		"baz/./foo", "baz//foo", "baz/", `..\..\bar`, `baz\..\..\bar`, `\bar`,
+		`C:\bar`, `C:bar`, "C:/bar", `\\host\share\bar`, "//host/share/bar"} {
+		edit("binsanity.go", `	"bar",`, "\t"+strconv.Quote(name)+",")
+		assert.EqualError(extract(true), fmt.Sprintf("%s: Unsafe asset name: %q", file, name))
+	}
+
+	// Bad data.
+	edit("binsanity.go", `"H4sI`, `"!!!!`)
+	assert.ErrorContains(extract(true), file+": bar: ", "data")
+
+	// Not there.
+	_, err = binsanity.Extract(filepath.Join(tdir, "nope.go"), tdir, false)
+	assert.Error(err, "missing")
+
+	// Can't write.
+	WriteTree(t, tdir, map[string]string{"binsanity.go": files["binsanity.go"]})
+	WriteTree(t, tdir, map[string]string{"out2/baz": "a file, not a dir"})
+	_, err = binsanity.Extract(file, filepath.Join(tdir, "out2"), true)
+	assert.Error(err, "mkdir")
+	WriteTree(t, tdir, map[string]string{"out3/bar/x": "a dir, not a file"})
+	_, err = binsanity.Extract(file, filepath.Join(tdir, "out3"), true)
+	assert.Error(err, "write")
+
+}
+
+func TestRunAppExtract(t *testing.T) {
+
+	assert := assert.New(t)
+
+	exit_code := 0
+	binsanity.ExitFunc = func(c int) { exit_code = c }
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	binsanity.OutWriter = stdout
+	binsanity.ErrWriter = stderr
+	defer RestoreDefaults()
+
+	odir := t.TempDir()
+	args := []string{"appname", "extract", filepath.Join(ExampleDir, "binsanity.go"), odir}
+	binsanity.RunApp(args)
+	assert.Equal(0, exit_code, "exit 0")
+	assert.Equal("files: 4, bytes: 58, verified\n", stdout.String(), "stdout")
+	assert.Equal(ReadTree(t, ExampleAssetDir), ReadTree(t, odir), "assets")
+
+	// Not again, unless forced.
+	binsanity.RunApp(args)
+	assert.Equal(1, exit_code, "exit 1")
+	assert.Equal(filepath.Join(odir, "bar")+": File exists.\n", stderr.String(), "stderr")
+	exit_code = 0
+	binsanity.RunApp(append([]string{"appname", "extract", "--force"}, args[2:]...))
+	assert.Equal(0, exit_code, "exit 0")
+
+	stderr.Reset()
+	binsanity.RunApp(args[:3])
+	assert.Equal(1, exit_code, "exit 1")
+	assert.Equal("Two args required: FILE.go OUTDIR\n", stderr.String(), "stderr")
+
+}
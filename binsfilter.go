@@ -0,0 +1,58 @@
+// binsfilter.go -- binsanity filtering of assets by name.
+
+package binsanity
+
+import (
+	"path"
+	"strings"
+)
+
+// MatchName returns true if the asset name matches pattern, in the syntax
+// of path.Match.  A pattern matches if it matches the whole name or any of
+// its parent directories, so "img" matches everything under "img/"; and a
+// pattern without a slash also matches the last element alone, so "*.txt"
+// matches "docs/a.txt".  A bad pattern matches nothing.
+func MatchName(pattern, name string) bool {
+
+	if !strings.Contains(pattern, "/") {
+		if ok, _ := path.Match(pattern, path.Base(name)); ok {
+			return true
+		}
+	}
+	for p := name; p != "." && p != "/"; p = path.Dir(p) {
+		if ok, _ := path.Match(pattern, p); ok {
+			return true
+		}
+	}
+	return false
+
+}
+
+// matchAny returns true if any of the patterns matches the asset name.
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if MatchName(pattern, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// wanted returns true if the asset name passes the filters in cfg: it is
+// not excluded, and included if anything is.
+func wanted(cfg *Config, name string) bool {
+	if matchAny(cfg.Exclude, name) {
+		return false
+	}
+	return len(cfg.Include) == 0 || matchAny(cfg.Include, name)
+}
+
+// checkPatterns returns an error for the first bad pattern, if any.
+func checkPatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
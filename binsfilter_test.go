@@ -0,0 +1,130 @@
+// binsfilter_test.go - tests for stuff in binsfilter.go
+package binsanity_test
+
+import (
+	"bytes"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/biztos/binsanity"
+)
+
+func TestMatchName(t *testing.T) {
+
+	assert := assert.New(t)
+
+	for _, c := range []struct {
+		pattern string
+		name    string
+		match   bool
+	}{
+		{"foo", "foo", true},
+		{"foo", "baz/foo", true},
+		{"foo", "foo/bar", true},
+		{"foo", "foobar", false},
+		{"*.txt", "a/b/c.txt", true},
+		{"*.txt", "a.txt/b", true},
+		{"*.txt", "a/b/c.txt.bak", false},
+		{"baz/*", "baz/foo", true},
+		{"baz/*", "baz/bat/bloopf", true},
+		{"baz/*", "bar", false},
+		{"*/foo", "foo", false},
+		{"baz/bat", "baz/bat/bloopf", true},
+		{"bat/bloopf", "baz/bat/bloopf", false},
+		{"[", "[", false},
+	} {
+		assert.Equal(c.match, binsanity.MatchName(c.pattern, c.name),
+			"%q %q", c.pattern, c.name)
+	}
+
+}
+
+func TestProcessFilters(t *testing.T) {
+
+	assert := assert.New(t)
+
+	tdir := t.TempDir()
+	names := func(include, exclude []string) []string {
+		cfg := &binsanity.Config{
+			Dir:     ExampleAssetDir,
+			File:    filepath.Join(tdir, "binsanity.go"),
+			Package: "foo",
+			Module:  "example.com/foo",
+			DryRun:  true,
+			Include: include,
+			Exclude: exclude,
+		}
+		res, err := binsanity.Process(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		list := []string{}
+		for _, a := range res.Assets {
+			list = append(list, a.Name)
+		}
+		return list
+	}
+
+	assert.Equal([]string{"bar", "baz/bat/bloopf", "baz/foo", "foo"},
+		names(nil, nil), "all")
+	assert.Equal([]string{"bar", "foo"},
+		names(nil, []string{"baz"}), "exclude dir")
+	assert.Equal([]string{"baz/foo", "foo"},
+		names([]string{"*oo"}, nil), "include")
+	assert.Equal([]string{"baz/foo"},
+		names([]string{"baz"}, []string{"bat"}), "both")
+	assert.Equal([]string{},
+		names([]string{"nope"}, nil), "none")
+
+}
+
+func TestProcessErrBadPattern(t *testing.T) {
+
+	assert := assert.New(t)
+
+	cfg := &binsanity.Config{
+		Dir:     ExampleAssetDir,
+		File:    filepath.Join(t.TempDir(), "binsanity.go"),
+		Package: "foo",
+		Module:  "example.com/foo",
+		Include: []string{"ok", "[bad"},
+	}
+	_, err := binsanity.Process(cfg)
+	assert.EqualError(err, "Bad include pattern: syntax error in pattern")
+
+	cfg.Include = nil
+	cfg.Exclude = []string{"[bad"}
+	_, err = binsanity.Process(cfg)
+	assert.EqualError(err, "Bad exclude pattern: syntax error in pattern")
+
+}
+
+func TestRunAppFilters(t *testing.T) {
+
+	assert := assert.New(t)
+
+	exit_code := 0
+	binsanity.ExitFunc = func(c int) { exit_code = c }
+	stdout := &bytes.Buffer{}
+	binsanity.OutWriter = stdout
+	defer RestoreDefaults()
+
+	binsanity.RunApp([]string{
+		"appname",
+		"--package=main",
+		"--module=biztos.com/example",
+		"--output=" + filepath.Join(t.TempDir(), "binsanity.go"),
+		"--dry-run",
+		"--exclude=baz",
+		"--include=b*",
+		"--include=f*",
+		ExampleAssetDir,
+	})
+	assert.Equal(0, exit_code, "exit 0")
+	assert.Regexp(regexp.MustCompile(`^NAME.*\nbar .*\nfoo .*\nfiles: 2, bytes: 24\n$`),
+		stdout.String(), "stdout")
+
+}
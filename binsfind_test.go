@@ -162,8 +162,8 @@ func TestGoFilesBySizeDirOk(t *testing.T) {
 	exp := []string{
 		filepath.Join(ExampleDir, "small.go"),
 		filepath.Join(ExampleDir, "main.go"),
-		filepath.Join(ExampleDir, "binsanity.go"),
 		filepath.Join(ExampleDir, "medium.go"),
+		filepath.Join(ExampleDir, "binsanity.go"),
 		filepath.Join(ExampleDir, "big.go"),
 	}
 	assert.EqualValues(exp, files, "expected files in size order")
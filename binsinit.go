@@ -0,0 +1,297 @@
+// binsinit.go -- binsanity go:generate bootstrapping.
+
+package binsanity
+
+import (
+	"bufio"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DirectivePrefix starts a go:generate directive that runs binsanity.
+const DirectivePrefix = "//go:generate binsanity"
+
+// DocFile is the file a go:generate directive is added to, if no file has
+// the package doc comment.
+const DocFile = "doc.go"
+
+// GenerateDirective returns a go:generate directive line that generates
+// cfg.File from cfg.Dir with the options in cfg, run from the directory of
+// cfg.File as go generate does.  The package and module are only included
+// if set, and the run options (Jobs, Force and so on) never are.
+func GenerateDirective(cfg *Config) (string, error) {
+
+	file := cfg.File
+	if file == "" {
+		file = "binsanity.go"
+	}
+	absfile, err := filepath.Abs(file)
+	if err != nil {
+		return "", err
+	}
+	absdir, err := filepath.Abs(cfg.Dir)
+	if err != nil {
+		return "", err
+	}
+	dir, err := filepath.Rel(filepath.Dir(absfile), absdir)
+	if err != nil {
+		return "", err
+	}
+
+	args := []string{DirectivePrefix}
+	if base := filepath.Base(file); base != "binsanity.go" {
+		args = append(args, "-o", quoteArg(base))
+	}
+	if cfg.Package != "" {
+		args = append(args, "--package="+quoteArg(cfg.Package))
+	}
+	if cfg.Module != "" {
+		args = append(args, "--module="+quoteArg(cfg.Module))
+	}
+	if cfg.SplitSize > 0 {
+		args = append(args, fmt.Sprintf("--split-size=%d", cfg.SplitSize))
+	}
+	for _, pattern := range cfg.Include {
+		args = append(args, "--include="+quoteArg(pattern))
+	}
+	for _, pattern := range cfg.Exclude {
+		args = append(args, "--exclude="+quoteArg(pattern))
+	}
+	if cfg.Compat {
+		args = append(args, "--compat")
+	}
+	if cfg.Meta {
+		args = append(args, "--meta")
+	}
+	if cfg.FS {
+		args = append(args, "--fs")
+	}
+	args = append(args, quoteArg(filepath.ToSlash(dir)))
+	return strings.Join(args, " "), nil
+
+}
+
+// quoteArg quotes arg for a go:generate directive if it needs it.
+func quoteArg(arg string) string {
+	if arg == "" || strings.ContainsAny(arg, " \t\"\\") {
+		return strconv.Quote(arg)
+	}
+	return arg
+}
+
+// FindDirective returns the first non-test Go file in dir with a binsanity
+// go:generate directive for the same output file and asset dir as directive,
+// and that directive, or empty strings if there is none.  Directives for
+// other files or asset dirs are another set of assets, so are passed over.
+func FindDirective(dir, directive string) (string, string, error) {
+
+	files, err := goFiles(dir)
+	if err != nil {
+		return "", "", err
+	}
+	output, assets := directiveTarget(directive)
+	for _, file := range files {
+		lines, err := readDirectives(file)
+		if err != nil {
+			return "", "", err
+		}
+		for _, line := range lines {
+			if o, a := directiveTarget(line); o == output && a == assets {
+				return file, line, nil
+			}
+		}
+	}
+	return "", "", nil
+
+}
+
+// goFiles returns the non-test Go files in dir, sorted.
+func goFiles(dir string) ([]string, error) {
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+	res := []string{}
+	for _, file := range files {
+		if !strings.HasSuffix(file, "_test.go") {
+			res = append(res, file)
+		}
+	}
+	return res, nil
+
+}
+
+// readDirectives returns the binsanity go:generate directives in the file.
+func readDirectives(file string) ([]string, error) {
+
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	lines := []string{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		if line == DirectivePrefix || strings.HasPrefix(line, DirectivePrefix+" ") {
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+
+}
+
+// directiveTarget returns the output file and asset dir of the binsanity
+// go:generate directive, cleaned, as binsanity would take them from its
+// arguments.  The output defaults to "binsanity.go", and the asset dir is
+// empty if there is none.
+func directiveTarget(directive string) (string, string) {
+
+	output, assets := "binsanity.go", ""
+	args := directiveArgs(strings.TrimPrefix(directive, DirectivePrefix))
+	for idx := 0; idx < len(args); idx++ {
+		arg := args[idx]
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			assets = filepath.Clean(filepath.FromSlash(arg))
+			break // no more options after the first argument
+		}
+		if arg == "--" {
+			if idx+1 < len(args) {
+				assets = filepath.Clean(filepath.FromSlash(args[idx+1]))
+			}
+			break
+		}
+		name, value := strings.TrimLeft(arg, "-"), ""
+		has_value := false
+		if i := strings.Index(name, "="); i >= 0 {
+			name, value, has_value = name[:i], name[i+1:], true
+		}
+		if !has_value && valueOptions[name] && idx+1 < len(args) {
+			idx++
+			value = args[idx]
+		}
+		if name == "output" || name == "o" {
+			output = filepath.Clean(filepath.FromSlash(value))
+		}
+	}
+	return output, assets
+
+}
+
+// valueOptions are the options that take a value, which may be the next
+// argument.
+var valueOptions = map[string]bool{
+	"config":     true,
+	"c":          true,
+	"output":     true,
+	"o":          true,
+	"module":     true,
+	"m":          true,
+	"package":    true,
+	"p":          true,
+	"split-size": true,
+	"include":    true,
+	"exclude":    true,
+	"jobs":       true,
+	"j":          true,
+}
+
+// directiveArgs splits the arguments of a go:generate directive as go
+// generate does: at spaces and tabs, with double-quoted Go strings unquoted.
+func directiveArgs(s string) []string {
+
+	args := []string{}
+	for {
+		s = strings.TrimLeft(s, " \t")
+		if s == "" {
+			return args
+		}
+		if s[0] == '"' {
+			if quoted, err := strconv.QuotedPrefix(s); err == nil {
+				arg, _ := strconv.Unquote(quoted) // can't fail, it's a prefix
+				args = append(args, arg)
+				s = s[len(quoted):]
+				continue
+			}
+		}
+		end := strings.IndexAny(s, " \t")
+		if end < 0 {
+			end = len(s)
+		}
+		args = append(args, s[:end])
+		s = s[end:]
+	}
+
+}
+
+// docFile returns the non-test Go file in dir with the package doc comment,
+// leaving out generated files, or DocFile there if there is none.
+func docFile(dir string) (string, error) {
+
+	files, err := goFiles(dir)
+	if err != nil {
+		return "", err
+	}
+	fset := token.NewFileSet()
+	for _, file := range files {
+		f, err := parser.ParseFile(fset, file, nil, parser.PackageClauseOnly|parser.ParseComments)
+		if err != nil || f.Doc == nil {
+			continue // not our problem here, go build will say
+		}
+		generated := false
+		for _, c := range f.Comments {
+			generated = generated || generatedComment.MatchString(c.Text())
+		}
+		if !generated {
+			return file, nil
+		}
+	}
+	return filepath.Join(dir, DocFile), nil
+
+}
+
+// generatedComment matches the Go convention for marking generated files,
+// in the text of their first comment.
+var generatedComment = regexp.MustCompile(`(?m)^Code generated .* DO NOT EDIT\.$`)
+
+// AddDirective adds the go:generate directive to dir, unless one for the
+// same output file and asset dir is already in any Go file there.  It is
+// added to the file with the package doc comment, or else to DocFile,
+// created for package pkg if need be.  The file with the directive is
+// returned, and the directive that was already there, which is empty if it
+// was added.
+func AddDirective(dir, pkg, directive string) (string, string, error) {
+
+	file, existing, err := FindDirective(dir, directive)
+	if err != nil || file != "" {
+		return file, existing, err
+	}
+
+	file, err = docFile(dir)
+	if err != nil {
+		return "", "", err
+	}
+	b, err := os.ReadFile(file)
+	if os.IsNotExist(err) {
+		b = []byte(fmt.Sprintf("// Package %s embeds its assets with binsanity.\npackage %s\n", pkg, pkg))
+	} else if err != nil {
+		return "", "", err
+	}
+	src := string(b)
+	if !strings.HasSuffix(src, "\n") {
+		src += "\n"
+	}
+	src += "\n" + directive + "\n"
+	if err := os.WriteFile(file, []byte(src), 0644); err != nil {
+		return "", "", err
+	}
+	return file, "", nil
+
+}
@@ -0,0 +1,345 @@
+// binsinit_test.go - tests for stuff in binsinit.go
+package binsanity_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/biztos/binsanity"
+)
+
+func TestGenerateDirective(t *testing.T) {
+
+	assert := assert.New(t)
+
+	tdir := t.TempDir()
+	directive := func(cfg *binsanity.Config) string {
+		s, err := binsanity.GenerateDirective(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+
+	assert.Equal("//go:generate binsanity testdata/example/assets",
+		directive(&binsanity.Config{Dir: ExampleAssetDir}), "defaults")
+	assert.Equal("//go:generate binsanity assets",
+		directive(&binsanity.Config{
+			Dir:  filepath.Join(tdir, "pkg", "assets"),
+			File: filepath.Join(tdir, "pkg", "binsanity.go"),
+			Jobs: 3, Force: true, Check: true, DryRun: true,
+		}), "run options left out")
+	assert.Equal(`//go:generate binsanity -o gen.go --package=foo `+
+		`--module=example.com/foo --split-size=1000 --include="my docs" `+
+		`--exclude=*.bak --exclude=drafts --compat --meta --fs ../assets`,
+		directive(&binsanity.Config{
+			Dir:       filepath.Join(tdir, "assets"),
+			File:      filepath.Join(tdir, "pkg", "gen.go"),
+			Package:   "foo",
+			Module:    "example.com/foo",
+			SplitSize: 1000,
+			Include:   []string{"my docs"},
+			Exclude:   []string{"*.bak", "drafts"},
+			Compat:    true,
+			Meta:      true,
+			FS:        true,
+		}), "everything")
+
+}
+
+func TestAddDirective(t *testing.T) {
+
+	assert := assert.New(t)
+
+	tdir := t.TempDir()
+	line := "//go:generate binsanity assets"
+
+	// A new doc.go.
+	file, existing, err := binsanity.AddDirective(tdir, "foo", line)
+	if !assert.Nil(err, "no error") {
+		return
+	}
+	assert.Equal(filepath.Join(tdir, "doc.go"), file, "file")
+	assert.Equal("", existing, "added")
+	assert.Equal("// Package foo embeds its assets with binsanity.\n"+
+		"package foo\n\n"+line+"\n", readAll(t, tdir)["doc.go"], "content")
+
+	// Not again.
+	file, existing, err = binsanity.AddDirective(tdir, "foo", line+" --force")
+	assert.Nil(err, "no error")
+	assert.Equal(filepath.Join(tdir, "doc.go"), file, "file again")
+	assert.Equal(line, existing, "not added again")
+	assert.Equal(1, strings.Count(readAll(t, tdir)["doc.go"], "go:generate"), "once")
+
+	// An existing doc.go is added to, ignoring test files and lookalikes.
+	tdir = t.TempDir()
+	WriteTree(t, tdir, map[string]string{
+		"doc.go":      "// Package bar is here.\npackage bar",
+		"a_test.go":   "package bar\n\n" + line + "\n",
+		"b.go":        "package bar\n\n//go:generate binsanityx assets\n",
+		"c.go.orig":   line + "\n",
+		"d/e.go":      line + "\n",
+		"f.txt":       line + "\n",
+		"g/binsanity": "",
+	})
+	file, existing, err = binsanity.AddDirective(tdir, "nope", line)
+	assert.Nil(err, "no error")
+	assert.Equal("", existing, "added")
+	assert.Equal("// Package bar is here.\npackage bar\n\n"+line+"\n",
+		readAll(t, tdir)["doc.go"], "appended")
+
+	// Some other file has it, with other options.
+	tdir = t.TempDir()
+	WriteTree(t, tdir, map[string]string{"gen.go": "package x\n\n" + line + " --meta \r\n"})
+	file, existing, err = binsanity.AddDirective(tdir, "x", line)
+	assert.Nil(err, "no error")
+	assert.Equal(filepath.Join(tdir, "gen.go"), file, "found")
+	assert.Equal(line+" --meta", existing, "not added")
+	assert.NoFileExists(filepath.Join(tdir, "doc.go"))
+
+	// Another output file or asset dir is another set, added alongside.
+	for _, other := range []string{
+		"//go:generate binsanity -o other.go assets",
+		"//go:generate binsanity other",
+	} {
+		file, existing, err = binsanity.AddDirective(tdir, "x", other)
+		assert.Nil(err, "no error")
+		assert.Equal(filepath.Join(tdir, "doc.go"), file, "added for %s", other)
+		assert.Equal("", existing, "added for %s", other)
+	}
+	assert.Equal("// Package x embeds its assets with binsanity.\npackage x\n\n"+
+		"//go:generate binsanity -o other.go assets\n\n"+
+		"//go:generate binsanity other\n", readAll(t, tdir)["doc.go"], "both")
+
+}
+
+func TestFindDirective(t *testing.T) {
+
+	assert := assert.New(t)
+
+	// The same output and asset dir however they are given, and not only in
+	// the first directive in a file.
+	tdir := t.TempDir()
+	found := map[string]string{
+		"a.go": `//go:generate binsanity --output "x.go" --package foo ./assets/`,
+		"b.go": `//go:generate binsanity -p=foo -o=./x.go -- "my assets"`,
+		"c.go": `//go:generate binsanity --split-size 10 -j 2 -`,
+	}
+	WriteTree(t, tdir, map[string]string{
+		"a.go": "package foo\n\n" + found["a.go"] + "\n",
+		"b.go": "package foo\n\n//go:generate binsanity -o x.go assets\n" + found["b.go"] + "\n",
+		"c.go": "package foo\n\n" + found["c.go"] + "\n",
+	})
+	for name, directive := range map[string]string{
+		"a.go": "//go:generate binsanity -o x.go assets",
+		"b.go": `//go:generate binsanity -o x.go "my assets"`,
+		"c.go": "//go:generate binsanity -",
+	} {
+		file, existing, err := binsanity.FindDirective(tdir, directive)
+		assert.Nil(err, "no error")
+		assert.Equal(filepath.Join(tdir, name), file, "file for %s", directive)
+		assert.Equal(found[name], existing, "found for %s", directive)
+	}
+
+	// Not for another output file, nor with no asset dir.
+	for _, directive := range []string{
+		"//go:generate binsanity assets",
+		"//go:generate binsanity -o y.go assets",
+		"//go:generate binsanity -o x.go",
+		"//go:generate binsanity -o x.go --",
+	} {
+		file, existing, err := binsanity.FindDirective(tdir, directive)
+		assert.Nil(err, "no error")
+		assert.Equal("", file, "no file for %s", directive)
+		assert.Equal("", existing, "none for %s", directive)
+	}
+
+}
+
+func TestAddDirectivePackageDoc(t *testing.T) {
+
+	assert := assert.New(t)
+
+	// The file with the package doc comment, but not a generated one.
+	tdir := t.TempDir()
+	line := "//go:generate binsanity assets"
+	WriteTree(t, tdir, map[string]string{
+		"a.go": "// Code generated by x; DO NOT EDIT.\n\n// Package foo is made.\npackage foo\n",
+		"b.go": "// Not parsable.\npackage",
+		"c.go": "package foo\n",
+		"d.go": "// Package foo does things.\npackage foo\n",
+		"e.go": "// Package foo does more things.\npackage foo\n",
+	})
+	file, existing, err := binsanity.AddDirective(tdir, "foo", line)
+	assert.Nil(err, "no error")
+	assert.Equal(filepath.Join(tdir, "d.go"), file, "file")
+	assert.Equal("", existing, "added")
+	files := readAll(t, tdir)
+	assert.Equal("// Package foo does things.\npackage foo\n\n"+line+"\n", files["d.go"], "content")
+	assert.NotContains(files["a.go"]+files["e.go"], line, "only there")
+	assert.NoFileExists(filepath.Join(tdir, "doc.go"))
+
+}
+
+func TestAddDirectiveErrors(t *testing.T) {
+
+	assert := assert.New(t)
+
+	// Unreadable Go file (a dir with a .go name).
+	tdir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(tdir, "x.go"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	_, _, err := binsanity.AddDirective(tdir, "x", "//go:generate binsanity a")
+	assert.Error(err, "scan")
+
+	// Unreadable doc.go.
+	tdir = t.TempDir()
+	WriteTree(t, tdir, map[string]string{"doc.go/x": ""})
+	_, _, err = binsanity.FindDirective(tdir, "//go:generate binsanity a")
+	assert.Error(err, "find")
+
+	// Bad glob.
+	_, _, err = binsanity.FindDirective("[", "//go:generate binsanity a")
+	assert.Error(err, "glob")
+
+}
+
+func TestRunAppInit(t *testing.T) {
+
+	assert := assert.New(t)
+
+	tdir := t.TempDir()
+	WriteTree(t, tdir, map[string]string{
+		"go.mod":          "module example.com/proj\n",
+		"pkg/assets/a":    "aaa",
+		"pkg/assets/b~":   "backup",
+		"pkg/existing.go": "package stuff\n",
+	})
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(filepath.Join(tdir, "pkg")); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	exit_code := 0
+	binsanity.ExitFunc = func(c int) { exit_code = c }
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	binsanity.OutWriter = stdout
+	binsanity.ErrWriter = stderr
+	defer RestoreDefaults()
+
+	args := []string{"appname", "init", "--exclude=*~", "assets"}
+	binsanity.RunApp(args)
+	assert.Equal(0, exit_code, "exit 0")
+	assert.Equal("files: 1, bytes: 3\n", stdout.String(), "stdout")
+	assert.Equal("Added to doc.go: //go:generate binsanity --exclude=*~ assets\n",
+		stderr.String(), "stderr")
+	files := readAll(t, ".")
+	assert.Equal("// Package stuff embeds its assets with binsanity.\n"+
+		"package stuff\n\n//go:generate binsanity --exclude=*~ assets\n",
+		files["doc.go"], "doc.go")
+	assert.Contains(files["binsanity.go"], "package stuff\n", "generated")
+	assert.Contains(files["binsanity.go"], `"module":"example.com/proj/pkg"`, "module")
+
+	// Again: no second directive.
+	stdout.Reset()
+	stderr.Reset()
+	binsanity.RunApp(args)
+	assert.Equal(0, exit_code, "exit 0")
+	assert.Equal("files: 1, bytes: 3\n", stdout.String(), "stdout")
+	assert.Equal("Already in doc.go: //go:generate binsanity --exclude=*~ assets\n",
+		stderr.String(), "stderr")
+	assert.Equal(files["doc.go"], readAll(t, ".")["doc.go"], "doc.go unchanged")
+
+	// Different options: generated, but the directive is left for the user.
+	stdout.Reset()
+	stderr.Reset()
+	binsanity.RunApp([]string{"appname", "init", "assets"})
+	assert.Equal(0, exit_code, "exit 0")
+	assert.Equal("files: 2, bytes: 9\n", stdout.String(), "stdout")
+	assert.Equal("Different directive in doc.go, left as is: "+
+		"//go:generate binsanity --exclude=*~ assets\n"+
+		"This run used: //go:generate binsanity assets\n", stderr.String(), "stderr")
+	assert.Equal(files["doc.go"], readAll(t, ".")["doc.go"], "doc.go still unchanged")
+
+	// Another output file is another set, so it is added too.
+	stdout.Reset()
+	stderr.Reset()
+	binsanity.RunApp([]string{"appname", "init", "--output=other.go", "assets"})
+	assert.Equal(0, exit_code, "exit 0")
+	assert.Equal("Added to doc.go: //go:generate binsanity -o other.go assets\n",
+		stderr.String(), "stderr")
+	assert.Equal(files["doc.go"]+"\n//go:generate binsanity -o other.go assets\n",
+		readAll(t, ".")["doc.go"], "doc.go with both")
+
+}
+
+func TestRunAppInitErrors(t *testing.T) {
+
+	assert := assert.New(t)
+
+	exit_code := 0
+	binsanity.ExitFunc = func(c int) { exit_code = c }
+	stderr := &bytes.Buffer{}
+	binsanity.OutWriter = &bytes.Buffer{}
+	binsanity.ErrWriter = stderr
+	defer RestoreDefaults()
+
+	binsanity.RunApp([]string{"appname", "init"})
+	assert.Equal(1, exit_code, "exit 1")
+	assert.Equal("Single arg required: ASSET_DIR\n", stderr.String(), "no arg")
+
+	// No module to be found, so nothing is added.
+	tdir := t.TempDir()
+	exit_code = 0
+	stderr.Reset()
+	binsanity.RunApp([]string{"appname", "init", "-o", filepath.Join(tdir, "x.go"), "assets"})
+	assert.Equal(1, exit_code, "exit 1")
+	assert.NoFileExists(filepath.Join(tdir, "doc.go"))
+
+	// No package to be found.
+	binsanity.FilePathAbs = func(string) (string, error) {
+		return "", errors.New("abs failed")
+	}
+	exit_code = 0
+	stderr.Reset()
+	binsanity.RunApp([]string{"appname", "init", "assets"})
+	assert.Equal(1, exit_code, "exit 1")
+	assert.Equal("abs failed\n", stderr.String(), "package")
+
+	// Can't add the directive.
+	RestoreDefaults()
+	binsanity.ExitFunc = func(c int) { exit_code = c }
+	binsanity.OutWriter = &bytes.Buffer{}
+	binsanity.ErrWriter = stderr
+	WriteTree(t, tdir, map[string]string{"go.mod": "module example.com/x\n"})
+	if err := os.Mkdir(filepath.Join(tdir, "bad.go"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	exit_code = 0
+	stderr.Reset()
+	binsanity.RunApp([]string{"appname", "init", "-p", "x", "-o", filepath.Join(tdir, "x.go"), "assets"})
+	assert.Equal(1, exit_code, "exit 1")
+	assert.Contains(stderr.String(), "bad.go", "directive")
+
+	// Nothing to generate from, so nothing is added.
+	tdir = t.TempDir()
+	WriteTree(t, tdir, map[string]string{"go.mod": "module example.com/x\n"})
+	exit_code = 0
+	binsanity.RunApp([]string{"appname", "init", "-p", "x", "-o", filepath.Join(tdir, "x.go"),
+		NonesuchDir})
+	assert.Equal(1, exit_code, "exit 1")
+	assert.NoFileExists(filepath.Join(tdir, "doc.go"))
+
+}
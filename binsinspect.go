@@ -0,0 +1,82 @@
+// binsinspect.go -- binsanity inspection of generated files.
+
+package binsanity
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Inspection describes the assets in a generated code file, as returned by
+// Inspect.  Config is nil for files from versions of binsanity that did not
+// record it.
+type Inspection struct {
+	File    string          `json:"file"`
+	Package string          `json:"package"`
+	Config  *RecordedConfig `json:"config"`
+	Assets  []*AssetResult  `json:"assets"`
+}
+
+// Inspect reads the code file generated by binsanity at file, and any
+// companion data files, and describes the assets in it with their original
+// and stored sizes, codec and sum.  The path of each asset is not known.
+func Inspect(file string) (*Inspection, error) {
+
+	gen, err := ReadGenerated(file)
+	if err != nil {
+		return nil, err
+	}
+	rec, err := ReadConfig(file)
+	if err != nil && !errors.Is(err, ErrNoConfig) {
+		return nil, err
+	}
+
+	ins := &Inspection{
+		File:    file,
+		Package: gen.Package,
+		Config:  rec,
+		Assets:  make([]*AssetResult, len(gen.Names)),
+	}
+	for idx, name := range gen.Names {
+		stored, err := gen.Stored(gen.Index[idx])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s: %v", file, name, err)
+		}
+		b, err := gen.Decode(gen.Index[idx])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s: %v", file, name, err)
+		}
+		ins.Assets[idx] = &AssetResult{
+			Name:   name,
+			Size:   len(b),
+			Stored: len(stored),
+			Codec:  Codec,
+			Sum:    fmt.Sprintf("%x", sha256.Sum256(b)),
+		}
+	}
+	return ins, nil
+
+}
+
+// String returns the pretty-print version of Inspection: the file, package,
+// version and config on a line each, followed by the table of assets.
+func (ins *Inspection) String() string {
+
+	version := "unknown"
+	config := "not recorded"
+	if ins.Config != nil {
+		version = ins.Config.Version
+		config = ins.Config.String()
+	}
+	lines := []string{
+		"file: " + ins.File,
+		"package: " + ins.Package,
+		"version: " + version,
+		"config: " + config,
+		AssetTable(ins.Assets),
+	}
+	return strings.Join(lines, "\n")
+
+}
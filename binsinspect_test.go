@@ -0,0 +1,146 @@
+// binsinspect_test.go - tests for stuff in binsinspect.go
+package binsanity_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/biztos/binsanity"
+)
+
+func TestInspect(t *testing.T) {
+
+	assert := assert.New(t)
+
+	file := filepath.Join(ExampleDir, "binsanity.go")
+	ins, err := binsanity.Inspect(file)
+	if !assert.Nil(err, "no error") {
+		return
+	}
+	assert.Equal(file, ins.File, "file")
+	assert.Equal("main", ins.Package, "package")
+	if assert.NotNil(ins.Config, "config") {
+		assert.Equal(binsanity.Version, ins.Config.Version, "version")
+		assert.Equal("biztos.com/example", ins.Config.Module, "module")
+	}
+	if assert.Equal(4, len(ins.Assets), "assets") {
+		assert.Equal(&binsanity.AssetResult{
+			Name:   "baz/bat/bloopf",
+			Size:   22,
+			Stored: 47,
+			Codec:  "gzip",
+			Sum:    "4fed59a4fcf97d28970b4a46c5eb52fc6ce9336fab8248073a6ba7c7b49aef59",
+		}, ins.Assets[1], "asset")
+	}
+
+	// Sizes and sums match what Process says.
+	res, err := binsanity.Process(&binsanity.Config{
+		Dir:     ExampleAssetDir,
+		File:    file,
+		Package: "main",
+		Module:  "biztos.com/example",
+		DryRun:  true,
+	})
+	if assert.Nil(err, "no error") {
+		for idx, a := range res.Assets {
+			a.Path = ""
+			assert.Equal(a, ins.Assets[idx], "same as processed")
+		}
+	}
+
+	lines := strings.Split(ins.String(), "\n")
+	assert.Equal([]string{
+		"file: " + file,
+		"package: main",
+		"version: " + binsanity.Version,
+		`config: {"version":"` + binsanity.Version +
+			`","dir":"assets","package":"main","module":"biztos.com/example"}`,
+	}, lines[:4], "header")
+	assert.Regexp(`^NAME +SIZE +STORED +RATIO +CODEC +SHA256$`, lines[4], "table")
+	assert.Equal(10, len(lines), "lines")
+
+}
+
+func TestInspectLegacy(t *testing.T) {
+
+	assert := assert.New(t)
+
+	ins, err := binsanity.Inspect(filepath.Join(LegacyDir, "binsanity.go"))
+	if !assert.Nil(err, "no error") {
+		return
+	}
+	assert.Nil(ins.Config, "no config")
+	assert.Equal(3, len(ins.Assets), "assets")
+	assert.Contains(ins.String(), "\nversion: unknown\nconfig: not recorded\n")
+
+}
+
+func TestInspectErrors(t *testing.T) {
+
+	assert := assert.New(t)
+
+	tdir := t.TempDir()
+	CopyTree(t, ExampleDir, tdir)
+	file := filepath.Join(tdir, "binsanity.go")
+	src := readAll(t, tdir)["binsanity.go"]
+	edit := func(old, new string) {
+		WriteTree(t, tdir, map[string]string{"binsanity.go": strings.Replace(src, old, new, 1)})
+	}
+
+	_, err := binsanity.Inspect(filepath.Join(tdir, "nope.go"))
+	assert.Error(err, "missing")
+	edit(`"H4sI`, `"!!!!`)
+	_, err = binsanity.Inspect(file)
+	assert.ErrorContains(err, file+": bar: illegal base64", "encoding")
+	edit(`"H4sI`, `"AAAA`)
+	_, err = binsanity.Inspect(file)
+	assert.ErrorContains(err, file+": bar: ", "compression")
+	edit(`"version":`, `"version"`)
+	_, err = binsanity.Inspect(file)
+	assert.ErrorContains(err, file+": config: ", "config")
+
+}
+
+func TestRunAppInspect(t *testing.T) {
+
+	assert := assert.New(t)
+
+	exit_code := 0
+	binsanity.ExitFunc = func(c int) { exit_code = c }
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	binsanity.OutWriter = stdout
+	binsanity.ErrWriter = stderr
+	defer RestoreDefaults()
+
+	file := filepath.Join(ExampleDir, "binsanity.go")
+	binsanity.RunApp([]string{"appname", "inspect", file})
+	assert.Equal(0, exit_code, "exit 0")
+	assert.True(strings.HasPrefix(stdout.String(), "file: "+file+"\npackage: main\n"), "text")
+	assert.Equal(9, strings.Count(stdout.String(), "\n"), "lines")
+
+	stdout.Reset()
+	binsanity.RunApp([]string{"appname", "inspect", "--json", file})
+	assert.Equal(0, exit_code, "exit 0")
+	ins := &binsanity.Inspection{}
+	if assert.Nil(json.Unmarshal(stdout.Bytes(), ins), "json") {
+		assert.Equal("main", ins.Package, "package")
+		assert.Equal("assets", ins.Config.Dir, "config")
+		assert.Equal(4, len(ins.Assets), "assets")
+	}
+
+	binsanity.RunApp([]string{"appname", "inspect"})
+	assert.Equal(1, exit_code, "exit 1")
+	assert.Equal("Single arg required: FILE.go\n", stderr.String(), "stderr")
+
+	exit_code = 0
+	stderr.Reset()
+	binsanity.RunApp([]string{"appname", "inspect", filepath.Join(ExampleDir, "nope.go")})
+	assert.Equal(1, exit_code, "exit 1")
+
+}
@@ -0,0 +1,451 @@
+// binsmigrate.go -- binsanity migration from go-bindata.
+
+package binsanity
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Bindata holds the assets found in a file generated by go-bindata, as
+// returned by ReadBindata.
+type Bindata struct {
+	File     string                 // the go-bindata file read
+	Package  string                 // its package
+	Names    []string               // sorted asset names
+	Assets   map[string][]byte      // content by name
+	Modes    map[string]os.FileMode // recorded mode by name, where there is one
+	ModTimes map[string]time.Time   // recorded modification time by name, likewise
+}
+
+// ReadBindata parses the file generated by go-bindata at file and recovers
+// its assets, without compiling or running anything.
+//
+// Each asset in the _bindata table is found by following its function to
+// the data it returns: a byte slice literal, a []byte conversion of a string
+// or a variable holding either.  Data passed through go-bindata's read
+// function is decompressed.  Files from old and new versions of go-bindata,
+// with or without compression, are supported; files generated in debug mode,
+// which read the assets from disk, are not.  The mode and modification time
+// recorded for each asset by newer versions are recovered too.
+func ReadBindata(file string) (*Bindata, error) {
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, file, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	r := &bindataReader{
+		funcs: map[string]*ast.FuncDecl{},
+		vars:  map[string]ast.Expr{},
+		seen:  map[string]bool{},
+	}
+	for _, decl := range f.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if d.Recv == nil && d.Body != nil {
+				r.funcs[d.Name.Name] = d
+			}
+		case *ast.GenDecl:
+			if d.Tok != token.VAR {
+				continue
+			}
+			for _, spec := range d.Specs {
+				vs := spec.(*ast.ValueSpec)
+				for idx, name := range vs.Names {
+					if idx < len(vs.Values) {
+						r.vars[name.Name] = vs.Values[idx]
+					}
+				}
+			}
+		}
+	}
+
+	table, ok := r.vars["_bindata"].(*ast.CompositeLit)
+	if !ok {
+		return nil, fmt.Errorf("%s: No go-bindata table found.", file)
+	}
+	bd := &Bindata{
+		File:     file,
+		Package:  f.Name.Name,
+		Names:    []string{},
+		Assets:   map[string][]byte{},
+		Modes:    map[string]os.FileMode{},
+		ModTimes: map[string]time.Time{},
+	}
+	for idx, elt := range table.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			return nil, fmt.Errorf("%s: _bindata: item %d: not a key-value pair", file, idx)
+		}
+		name, err := stringLit(kv.Key)
+		if err != nil {
+			return nil, fmt.Errorf("%s: _bindata: item %d: %v", file, idx, err)
+		}
+		b, compressed, found := r.data(kv.Value)
+		if !found {
+			return nil, fmt.Errorf("%s: No data found for %s.", file, name)
+		}
+		if compressed {
+			b, err = gunzip(b)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s: %v", file, name, err)
+			}
+		}
+		bd.Names = append(bd.Names, name)
+		bd.Assets[name] = b
+		if mode, mtime, found := r.info(kv.Value); found {
+			bd.Modes[name] = mode
+			bd.ModTimes[name] = mtime
+		}
+	}
+	sort.Strings(bd.Names)
+	return bd, nil
+
+}
+
+// stringLit returns the value of the string literal expr.
+func stringLit(expr ast.Expr) (string, error) {
+
+	bl, ok := expr.(*ast.BasicLit)
+	if !ok || bl.Kind != token.STRING {
+		return "", errors.New("not a string literal")
+	}
+	return strconv.Unquote(bl.Value)
+
+}
+
+// gunzip returns the decompressed content of b.
+func gunzip(b []byte) ([]byte, error) {
+
+	gzr, err := gzip.NewReader(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	defer gzr.Close()
+	return io.ReadAll(gzr)
+
+}
+
+// bindataReader follows the functions and variables in a go-bindata file to
+// the asset data.
+type bindataReader struct {
+	funcs map[string]*ast.FuncDecl
+	vars  map[string]ast.Expr
+	seen  map[string]bool // names being followed, so loops end
+}
+
+// data returns the data that expr leads to, and whether it is compressed.
+// If it doesn't lead to any, found is false.
+func (r *bindataReader) data(expr ast.Expr) (b []byte, compressed, found bool) {
+
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		s, err := stringLit(e)
+		return []byte(s), false, err == nil
+	case *ast.CompositeLit:
+		if isByteSlice(e.Type) {
+			return byteList(e.Elts)
+		}
+	case *ast.Ident:
+		if r.seen[e.Name] {
+			return nil, false, false
+		}
+		r.seen[e.Name] = true
+		defer delete(r.seen, e.Name)
+		if v, ok := r.vars[e.Name]; ok {
+			return r.data(v)
+		}
+		if fd, ok := r.funcs[e.Name]; ok {
+			return r.body(fd)
+		}
+	case *ast.CallExpr:
+		if isByteSlice(e.Fun) && len(e.Args) == 1 {
+			return r.data(e.Args[0])
+		}
+		id, ok := e.Fun.(*ast.Ident)
+		if !ok {
+			return nil, false, false
+		}
+		if id.Name == "bindataRead" || id.Name == "bindata_read" {
+			if len(e.Args) == 0 {
+				return nil, false, false
+			}
+			b, _, found := r.data(e.Args[0])
+			return b, true, found
+		}
+		return r.data(id)
+	}
+	return nil, false, false
+
+}
+
+// info returns the mode and modification time in the bindataFileInfo built
+// by the asset function named by expr.  If there is none, or they aren't
+// both plain numbers, found is false.
+func (r *bindataReader) info(expr ast.Expr) (mode os.FileMode, mtime time.Time, found bool) {
+
+	id, ok := expr.(*ast.Ident)
+	if !ok || r.funcs[id.Name] == nil {
+		return 0, time.Time{}, false
+	}
+	var has_mode, has_time bool
+	ast.Inspect(r.funcs[id.Name].Body, func(n ast.Node) bool {
+		cl, ok := n.(*ast.CompositeLit)
+		if !ok {
+			return true
+		}
+		if tid, ok := cl.Type.(*ast.Ident); !ok || tid.Name != "bindataFileInfo" {
+			return true
+		}
+		for _, elt := range cl.Elts {
+			kv, ok := elt.(*ast.KeyValueExpr)
+			if !ok {
+				continue
+			}
+			key, _ := kv.Key.(*ast.Ident)
+			call, _ := kv.Value.(*ast.CallExpr)
+			if key == nil || call == nil || len(call.Args) == 0 {
+				continue
+			}
+			switch key.Name {
+			case "mode":
+				if i, ok := intLit(call.Args[0]); ok {
+					mode, has_mode = os.FileMode(i), true
+				}
+			case "modTime":
+				if i, ok := intLit(call.Args[0]); ok {
+					mtime, has_time = time.Unix(i, 0), true
+				}
+			}
+		}
+		return false
+	})
+	return mode, mtime, has_mode && has_time
+
+}
+
+// intLit returns the value of the integer literal expr.
+func intLit(expr ast.Expr) (int64, bool) {
+
+	bl, ok := expr.(*ast.BasicLit)
+	if !ok || bl.Kind != token.INT {
+		return 0, false
+	}
+	i, err := strconv.ParseInt(bl.Value, 0, 64)
+	return i, err == nil
+
+}
+
+// body returns the first data returned by the function, or assigned from a
+// call in it, as for data.
+func (r *bindataReader) body(fd *ast.FuncDecl) ([]byte, bool, bool) {
+
+	for _, stmt := range fd.Body.List {
+		exprs := []ast.Expr{}
+		switch s := stmt.(type) {
+		case *ast.ReturnStmt:
+			exprs = s.Results
+		case *ast.AssignStmt:
+			for _, rhs := range s.Rhs {
+				if _, ok := rhs.(*ast.CallExpr); ok {
+					exprs = append(exprs, rhs)
+				}
+			}
+		}
+		for _, expr := range exprs {
+			if b, compressed, found := r.data(expr); found {
+				return b, compressed, true
+			}
+		}
+	}
+	return nil, false, false
+
+}
+
+// isByteSlice returns true if expr is the type []byte.
+func isByteSlice(expr ast.Expr) bool {
+
+	at, ok := expr.(*ast.ArrayType)
+	if !ok || at.Len != nil {
+		return false
+	}
+	id, ok := at.Elt.(*ast.Ident)
+	return ok && (id.Name == "byte" || id.Name == "uint8")
+
+}
+
+// byteList returns the bytes in a byte slice literal, as for data.
+func byteList(elts []ast.Expr) ([]byte, bool, bool) {
+
+	b := make([]byte, len(elts))
+	for idx, elt := range elts {
+		bl, ok := elt.(*ast.BasicLit)
+		if !ok || bl.Kind != token.INT {
+			return nil, false, false
+		}
+		i, err := strconv.ParseUint(bl.Value, 0, 8)
+		if err != nil {
+			return nil, false, false
+		}
+		b[idx] = byte(i)
+	}
+	return b, false, true
+
+}
+
+// Migrate recovers the assets in the go-bindata file at file into cfg.Dir,
+// keeping their names, and then calls Process with cfg to generate binsanity
+// code and tests for them.  The package defaults to that of the go-bindata
+// file.  If cfg.Meta is set, the recovered files get the modes and
+// modification times go-bindata recorded, where it did, so they are
+// recorded again; otherwise those are lost.
+//
+// Everything Process checks before reading assets is checked first, and
+// asset names that would escape cfg.Dir are rejected, as are existing files
+// unless cfg.Force is set, all before anything is written.  If writing the
+// assets or Process fails after all, the asset files and dirs written are
+// removed again and any replaced files put back.
+//
+// The go-bindata file itself is left alone, but as it defines some of the
+// same functions it has to be removed before its package will build.  Set
+// cfg.Compat for the other functions go-bindata generates, so their callers
+// keep working.
+func Migrate(file string, cfg *Config) (*Result, error) {
+
+	if cfg.Dir == "" {
+		return nil, errors.New("Source file not specified.")
+	}
+	bd, err := ReadBindata(file)
+	if err != nil {
+		return nil, err
+	}
+	mcfg := *cfg
+	if mcfg.Package == "" {
+		mcfg.Package = bd.Package
+	}
+	if _, _, _, _, err := checkConfig(&mcfg); err != nil {
+		return nil, err
+	}
+	if err := checkAssetPaths(file, cfg.Dir, bd.Names, cfg.Force); err != nil {
+		return nil, err
+	}
+
+	aw := &assetWriter{dir: cfg.Dir}
+	for _, name := range bd.Names {
+		err := aw.write(name, bd.Assets[name])
+		if err == nil && cfg.Meta {
+			if mtime, found := bd.ModTimes[name]; found {
+				err = aw.setMeta(name, bd.Modes[name], mtime)
+			}
+		}
+		if err != nil {
+			aw.undo()
+			return nil, err
+		}
+	}
+	res, err := Process(&mcfg)
+	if err != nil {
+		aw.undo()
+		return nil, err
+	}
+	return res, nil
+
+}
+
+// assetWriter writes assets under dir, keeping track of what it changed so
+// it can all be undone.
+type assetWriter struct {
+	dir      string
+	created  []string         // files and dirs made, in order
+	replaced []*replacedAsset // existing files written over
+}
+
+// replacedAsset is the former content of a file an assetWriter wrote over.
+type replacedAsset struct {
+	path string
+	b    []byte
+	info os.FileInfo
+}
+
+// write writes the content b of the named asset to its path under dir,
+// creating any dirs needed, as for writeAsset.
+func (aw *assetWriter) write(name string, b []byte) error {
+
+	path := filepath.Join(aw.dir, filepath.FromSlash(name))
+	if err := aw.mkdir(filepath.Dir(path)); err != nil {
+		return err
+	}
+	if info, err := os.Lstat(path); err == nil && info.Mode().IsRegular() {
+		old, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		aw.replaced = append(aw.replaced, &replacedAsset{path, old, info})
+	} else {
+		aw.created = append(aw.created, path)
+	}
+	return os.WriteFile(path, b, 0644)
+
+}
+
+// mkdir makes dir and any parents missing, remembering each.
+func (aw *assetWriter) mkdir(dir string) error {
+
+	if _, err := os.Stat(dir); err == nil {
+		return nil
+	}
+	if parent := filepath.Dir(dir); parent != dir {
+		if err := aw.mkdir(parent); err != nil {
+			return err
+		}
+	}
+	if err := os.Mkdir(dir, 0755); err != nil {
+		return err
+	}
+	aw.created = append(aw.created, dir)
+	return nil
+
+}
+
+// setMeta sets the mode and modification time of the named asset, once
+// written.
+func (aw *assetWriter) setMeta(name string, mode os.FileMode, mtime time.Time) error {
+
+	path := filepath.Join(aw.dir, filepath.FromSlash(name))
+	if err := os.Chmod(path, mode.Perm()); err != nil {
+		return err
+	}
+	return os.Chtimes(path, mtime, mtime)
+
+}
+
+// undo removes everything written and puts back what was replaced, as well
+// as it can.
+func (aw *assetWriter) undo() {
+
+	for idx := len(aw.created) - 1; idx >= 0; idx-- {
+		os.Remove(aw.created[idx])
+	}
+	for _, ra := range aw.replaced {
+		os.Remove(ra.path)
+		os.WriteFile(ra.path, ra.b, ra.info.Mode().Perm())
+		os.Chmod(ra.path, ra.info.Mode().Perm())
+		os.Chtimes(ra.path, ra.info.ModTime(), ra.info.ModTime())
+	}
+
+}
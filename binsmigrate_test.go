@@ -0,0 +1,330 @@
+// binsmigrate_test.go - tests for stuff in binsmigrate.go
+package binsanity_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/biztos/binsanity"
+)
+
+var BindataDir = filepath.Join("testdata", "bindata")
+
+var BindataAssets = map[string]string{
+	"assets/empty.txt":     "",
+	"assets/hello.txt":     "hello, world\n",
+	"assets/img/dot.bin":   "\x89PNG\x00\"\\\n\xff",
+	"assets/sub/data.json": `{"answer": 42}` + "\n",
+}
+
+func TestReadBindata(t *testing.T) {
+
+	assert := assert.New(t)
+
+	for _, dir := range []string{"", "nocompress", "old"} {
+		file := filepath.Join(BindataDir, dir, "bindata.go")
+		bd, err := binsanity.ReadBindata(file)
+		if !assert.Nil(err, "no error for %s", file) {
+			continue
+		}
+		assert.Equal(file, bd.File, "file")
+		assert.Equal("assets", bd.Package, "package")
+		assert.Equal(keys(BindataAssets), bd.Names, "names for %s", file)
+		for name, content := range BindataAssets {
+			assert.Equal(content, string(bd.Assets[name]), "%s in %s", name, file)
+		}
+		if dir == "old" {
+			assert.Empty(bd.Modes, "no modes in %s", file)
+			assert.Empty(bd.ModTimes, "no times in %s", file)
+			continue
+		}
+		assert.Equal(os.FileMode(0644), bd.Modes["assets/hello.txt"], "mode in %s", file)
+		assert.Equal(time.Unix(1600000000, 0), bd.ModTimes["assets/hello.txt"],
+			"time in %s", file)
+	}
+
+	// Info that isn't plain numbers is skipped.
+	tdir := t.TempDir()
+	WriteTree(t, tdir, map[string]string{"bindata.go": `package x
+
+var _bindata = map[string]func() (*asset, error){"a": a, "b": b, "c": c}
+
+func a() (*asset, error) {
+	info := bindataFileInfo{name: "a", mode: os.FileMode(m), modTime: time.Unix(1, 0)}
+	bytes, err := data()
+	return &asset{bytes: bytes, info: info}, err
+}
+
+func b() (*asset, error) {
+	info := bindataFileInfo{"b", 1, os.FileMode(0644), time.Now()}
+	bytes, err := data()
+	return &asset{bytes: bytes, info: info}, err
+}
+
+func c() (*asset, error) {
+	info := bindataFileInfo{name: "c", mode: os.FileMode(0600), modTime: time.Unix(2, 0)}
+	info2 := otherInfo{mode: os.FileMode(0644)}
+	bytes, err := data()
+	return &asset{bytes: bytes, info: info}, err
+}
+
+func data() ([]byte, error) { return []byte("x"), nil }
+`})
+	bd, err := binsanity.ReadBindata(filepath.Join(tdir, "bindata.go"))
+	if assert.Nil(err, "no error") {
+		assert.Equal(map[string]os.FileMode{"c": 0600}, bd.Modes, "modes")
+		assert.Equal(map[string]time.Time{"c": time.Unix(2, 0)}, bd.ModTimes, "times")
+	}
+
+}
+
+func TestReadBindataErrors(t *testing.T) {
+
+	assert := assert.New(t)
+
+	tdir := t.TempDir()
+	read := func(src string) error {
+		WriteTree(t, tdir, map[string]string{"bindata.go": "package x\n\n" + src})
+		_, err := binsanity.ReadBindata(filepath.Join(tdir, "bindata.go"))
+		return err
+	}
+
+	_, err := binsanity.ReadBindata(filepath.Join(tdir, "nope.go"))
+	assert.Error(err, "missing")
+	assert.ErrorContains(read("var x ="), "bindata.go:", "unparsable")
+	assert.ErrorContains(read("var x = 1"), "No go-bindata table found.", "no table")
+	assert.ErrorContains(read("var _bindata = map[string]int{x}"),
+		"_bindata: item 0: not a key-value pair", "not key-value")
+	assert.ErrorContains(read("var _bindata = map[int]int{1: x}"),
+		"_bindata: item 0: not a string literal", "not string")
+
+	// Things that aren't data.
+	for _, src := range []string{
+		"var a = b\nvar b = a",
+		"var a = 1",
+		"var a = []byte{1, x}",
+		"var a = []byte{0x100}",
+		"var a = []int{1}",
+		"var a = foo.Bar()",
+		"var a = bindataRead()",
+		"var a = string(x)",
+		"func a() ([]byte, error) { path := \"/tmp/x\"; return bindataRead(path, \"x\") }",
+	} {
+		assert.ErrorContains(read(`var _bindata = map[string]int{"x": a}`+"\n"+src),
+			"No data found for x.", "no data for %s", src)
+	}
+
+	assert.ErrorContains(read(`var _bindata = map[string]int{"x": a}
+var a = bindataRead([]byte("nope"), "x")`), "bindata.go: x: ", "bad gzip")
+
+}
+
+func TestMigrate(t *testing.T) {
+
+	assert := assert.New(t)
+
+	tdir := t.TempDir()
+	cfg := &binsanity.Config{
+		Dir:    filepath.Join(tdir, "assets"),
+		File:   filepath.Join(tdir, "binsanity.go"),
+		Module: "example.com/assets",
+		Compat: true,
+	}
+	file := filepath.Join(BindataDir, "bindata.go")
+	res, err := binsanity.Migrate(file, cfg)
+	if !assert.Nil(err, "no error") {
+		return
+	}
+	assert.Equal(4, res.Files, "files")
+	assert.Equal("", cfg.Package, "config untouched")
+
+	// Recovered with their names under the dir...
+	assert.Equal(BindataAssets, ReadTree(t, filepath.Join(tdir, "assets")), "recovered")
+
+	// ...and generated for in the same package, with the same names.
+	gen, err := binsanity.ReadGenerated(cfg.File)
+	if assert.Nil(err, "read") {
+		assert.Equal("assets", gen.Package, "package")
+		assert.Equal(keys(BindataAssets), gen.Names, "names")
+	}
+	rec, err := binsanity.ReadConfig(cfg.File)
+	if assert.Nil(err, "config") {
+		assert.True(rec.Compat, "compat")
+	}
+	src := readAll(t, tdir)["binsanity.go"]
+	assert.Contains(src, "\nfunc AssetDir(name string) ([]string, error) {\n", "compat")
+	assert.Contains(src, "\nfunc AssetInfo(name string) (os.FileInfo, error) {\n", "compat")
+	v, err := binsanity.Verify(cfg.File)
+	if assert.Nil(err, "verify") {
+		assert.True(v.OK(), "verified")
+	}
+
+	// Existing assets are left alone, unless forced.
+	_, err = binsanity.Migrate(file, cfg)
+	assert.ErrorContains(err, "File exists.", "exists")
+	cfg.Force = true
+	_, err = binsanity.Migrate(file, cfg)
+	assert.Nil(err, "forced")
+
+	// The recorded modes and times are kept with Meta.
+	info, err := os.Stat(filepath.Join(tdir, "assets", "assets", "img", "dot.bin"))
+	if assert.Nil(err, "stat") {
+		assert.Equal(os.FileMode(0644), info.Mode().Perm(), "mode without meta")
+	}
+	cfg.Meta = true
+	_, err = binsanity.Migrate(file, cfg)
+	if !assert.Nil(err, "meta") {
+		return
+	}
+	info, err = os.Stat(filepath.Join(tdir, "assets", "assets", "img", "dot.bin"))
+	if assert.Nil(err, "stat") {
+		assert.Equal(os.FileMode(0600), info.Mode().Perm(), "mode")
+		assert.Equal(time.Unix(1600000000, 0), info.ModTime(), "time")
+	}
+	rec, err = binsanity.ReadConfig(cfg.File)
+	if assert.Nil(err, "config") {
+		assert.True(rec.Meta, "meta")
+	}
+
+}
+
+func TestMigrateErrors(t *testing.T) {
+
+	assert := assert.New(t)
+
+	tdir := t.TempDir()
+	_, err := binsanity.Migrate(filepath.Join(BindataDir, "bindata.go"), &binsanity.Config{})
+	assert.EqualError(err, "Source file not specified.", "no dir")
+
+	_, err = binsanity.Migrate(filepath.Join(tdir, "nope.go"), &binsanity.Config{Dir: tdir})
+	assert.Error(err, "missing")
+
+	WriteTree(t, tdir, map[string]string{
+		"evil.go": "package x\n\nvar _bindata = map[string]int{\"../evil\": a}\nvar a = []byte(\"!\")\n",
+	})
+	_, err = binsanity.Migrate(filepath.Join(tdir, "evil.go"), &binsanity.Config{
+		Dir: filepath.Join(tdir, "assets"),
+	})
+	assert.ErrorContains(err, `Unsafe asset name: "../evil"`, "unsafe")
+	assert.NoDirExists(filepath.Join(tdir, "assets"), "nothing written")
+	WriteTree(t, tdir, map[string]string{
+		"evil.go": "package x\n\nvar _bindata = map[string]int{`..\\evil`: a}\nvar a = []byte(\"!\")\n",
+	})
+	_, err = binsanity.Migrate(filepath.Join(tdir, "evil.go"), &binsanity.Config{
+		Dir: filepath.Join(tdir, "assets"),
+	})
+	assert.ErrorContains(err, `Unsafe asset name: "..\\evil"`, "unsafe on windows")
+	assert.NoDirExists(filepath.Join(tdir, "assets"), "nothing written")
+
+	// The config is checked before anything is written.
+	WriteTree(t, tdir, map[string]string{"mine/binsanity.go": "package mine\n"})
+	_, err = binsanity.Migrate(filepath.Join(BindataDir, "bindata.go"), &binsanity.Config{
+		Dir:    filepath.Join(tdir, "mine", "assets"),
+		File:   filepath.Join(tdir, "mine", "binsanity.go"),
+		Module: "example.com/mine",
+	})
+	assert.ErrorIs(err, binsanity.ErrNotGenerated, "not generated")
+	assert.NoDirExists(filepath.Join(tdir, "mine", "assets"), "nothing written")
+
+	// A later failure undoes the writing, putting back what was replaced.
+	WriteTree(t, tdir, map[string]string{"undo/assets/assets/hello.txt": "old"})
+	hello := filepath.Join(tdir, "undo", "assets", "assets", "hello.txt")
+	if err := os.Chmod(hello, 0600); err != nil {
+		t.Fatal(err)
+	}
+	binsanity.CodeTemplate = "{{.Nope}}"
+	defer RestoreDefaults()
+	_, err = binsanity.Migrate(filepath.Join(BindataDir, "bindata.go"), &binsanity.Config{
+		Dir:    filepath.Join(tdir, "undo", "assets"),
+		File:   filepath.Join(tdir, "undo", "binsanity.go"),
+		Module: "example.com/undo",
+		Force:  true,
+	})
+	assert.ErrorContains(err, "render ", "render")
+	assert.Equal(map[string]string{"assets/hello.txt": "old"},
+		ReadTree(t, filepath.Join(tdir, "undo", "assets")), "undone")
+	info, err := os.Stat(hello)
+	if assert.Nil(err, "stat") {
+		assert.Equal(os.FileMode(0600), info.Mode().Perm(), "mode put back")
+	}
+	assert.NoDirExists(filepath.Join(tdir, "undo", "assets", "assets", "img"), "dirs removed")
+	RestoreDefaults()
+
+	// A file in the way of a directory.
+	WriteTree(t, tdir, map[string]string{"in/assets/assets/img": "file"})
+	_, err = binsanity.Migrate(filepath.Join(BindataDir, "bindata.go"), &binsanity.Config{
+		Dir:    filepath.Join(tdir, "in", "assets"),
+		File:   filepath.Join(tdir, "in", "binsanity.go"),
+		Module: "example.com/in",
+	})
+	assert.Error(err, "not a dir")
+
+}
+
+func TestRunAppMigrate(t *testing.T) {
+
+	assert := assert.New(t)
+
+	exit_code := 0
+	binsanity.ExitFunc = func(c int) { exit_code = c }
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	binsanity.OutWriter = stdout
+	binsanity.ErrWriter = stderr
+	defer RestoreDefaults()
+
+	tdir := t.TempDir()
+	CopyTree(t, BindataDir, tdir)
+	file := filepath.Join(tdir, "bindata.go")
+	out := filepath.Join(tdir, "binsanity.go")
+
+	binsanity.RunApp([]string{"appname", "migrate", "-o", out, "-m", "example.com/x",
+		"--compat", file, filepath.Join(tdir, "static")})
+	assert.Equal(0, exit_code, "exit 0")
+	assert.Equal("files: 4, bytes: 37\n", stdout.String(), "stdout")
+	assert.Equal("Remove "+file+" before building: it defines the same functions.\n",
+		stderr.String(), "reminder")
+	assert.FileExists(filepath.Join(tdir, "static", "assets", "hello.txt"), "recovered")
+	rec, err := binsanity.ReadConfig(out)
+	if assert.Nil(err, "config") {
+		assert.Equal("static", rec.Dir, "dir")
+		assert.True(rec.Compat, "compat")
+	}
+
+	// Somewhere else, as JSON, with the default asset dir.
+	stdout.Reset()
+	stderr.Reset()
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+	if err := os.Chdir(filepath.Join(tdir, "old")); err != nil {
+		t.Fatal(err)
+	}
+	WriteTree(t, ".", map[string]string{"gen/doc.go": "package gen\n"})
+	binsanity.RunApp([]string{"appname", "migrate", "-o", "gen/binsanity.go",
+		"-m", "example.com/x", "--json", "bindata.go"})
+	assert.Equal(0, exit_code, "exit 0")
+	assert.Equal("", stderr.String(), "no reminder")
+	res := &binsanity.Result{}
+	if assert.Nil(json.Unmarshal(stdout.Bytes(), res), "json") {
+		assert.Equal(4, res.Files, "files")
+	}
+	assert.FileExists(filepath.Join("assets", "assets", "empty.txt"), "default dir")
+
+	binsanity.RunApp([]string{"appname", "migrate"})
+	assert.Equal(1, exit_code, "exit 1")
+	assert.Equal("One or two args required: BINDATA.go [ASSET_DIR]\n", stderr.String(), "stderr")
+
+	exit_code = 0
+	stderr.Reset()
+	binsanity.RunApp([]string{"appname", "migrate", "nope.go"})
+	assert.Equal(1, exit_code, "exit 1")
+	assert.True(strings.HasPrefix(stderr.String(), "open nope.go: "), "error")
+
+}
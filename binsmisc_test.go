@@ -4,7 +4,9 @@ package binsanity_test
 
 import (
 	"os"
+	"os/signal"
 	"path/filepath"
+	"testing"
 
 	"github.com/biztos/binsanity"
 )
@@ -15,12 +17,95 @@ var ExampleAssetDir = filepath.Join(ExampleDir, "assets")
 var NonesuchDir = filepath.Join("testdata", "nope")
 var ScanDir = filepath.Join("testdata", "scan")
 
+var DefaultCompressor = binsanity.NewCompressor
+var DefaultMaxBuffered = binsanity.MaxBuffered
+var DefaultWatchInterval = binsanity.WatchInterval
+var DefaultWatchDebounce = binsanity.WatchDebounce
+
 func RestoreDefaults() {
 
 	binsanity.FilePathAbs = filepath.Abs
+	binsanity.OsOpen = os.Open
+	binsanity.OsRename = os.Rename
+	binsanity.NewCompressor = DefaultCompressor
+	binsanity.MaxBuffered = DefaultMaxBuffered
+	binsanity.CodeTemplate = binsanity.MustAssetString("code.tmpl")
+	binsanity.TestTemplate = binsanity.MustAssetString("tests.tmpl")
+	binsanity.DataTemplate = binsanity.MustAssetString("data.tmpl")
 	binsanity.Args = os.Args
 	binsanity.ExitFunc = os.Exit
 	binsanity.OutWriter = os.Stdout
 	binsanity.ErrWriter = os.Stderr
+	binsanity.WatchInterval = DefaultWatchInterval
+	binsanity.WatchDebounce = DefaultWatchDebounce
+	binsanity.NotifySignal = signal.Notify
+	binsanity.StopSignal = signal.Stop
+
+}
+
+// WriteTree writes files (slash-separated names, string contents) under dir,
+// creating directories as needed.
+func WriteTree(t *testing.T, dir string, files map[string]string) {
+
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+}
+
+// CopyTree copies the files under src to dst, creating directories as needed.
+func CopyTree(t *testing.T, src, dst string) {
+
+	err := filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		WriteTree(t, dst, map[string]string{filepath.ToSlash(rel): string(b)})
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+}
+
+// ReadTree returns the contents of the files under dir by slash-separated
+// name.
+func ReadTree(t *testing.T, dir string) map[string]string {
+
+	files := map[string]string{}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = string(b)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
 
 }
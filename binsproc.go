@@ -3,33 +3,63 @@
 package binsanity
 
 import (
-	"bytes"
-	"compress/gzip"
-	"crypto/rand"
-	"crypto/sha256"
 	"encoding/base64"
 	"errors"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
 	"sort"
 	"strings"
 	"text/template"
+	"time"
 )
 
+// The templates used by Process, normally those in the assets directory.
+// They may be replaced, for instance to test how failures are handled.
+var CodeTemplate = MustAssetString("code.tmpl")
+var TestTemplate = MustAssetString("tests.tmpl")
+var DataTemplate = MustAssetString("data.tmpl")
+
 const DummyDataString = "H4sIAAAAAAAA/8rP5gIEAAD//30OFtoDAAAA"
 const DummyDataSum = "dc51b8c96c2d745df3bd5590d990230a482fd247123599548e0632fdbf97fc22"
 
 // Result is returned by Process and records the number of files and total
-// bytes processed.
+// bytes processed, and the number of encoded bytes kept out of the generated
+// source by storing identical content only once.
+//
+// Assets describes each asset in name order.  Stored is the total size of the
+// compressed data, each unique content counted once, and Source the total size
+// of the generated files (zero for a dry run).  Files skipped because they are
+// not regular files are listed in Skipped.
+//
+// Written lists the generated files actually written: those already up to
+// date are left untouched, so it is empty if nothing changed.
+//
+// When checking, Stale is set if the generated files are out of date, and
+// Changes describes how the assets changed since they were generated.
 type Result struct {
-	Files int
-	Bytes int
+	Files   int            `json:"files"`
+	Bytes   int            `json:"bytes"`
+	Saved   int            `json:"saved"`
+	Stored  int            `json:"stored"`
+	Source  int            `json:"source"`
+	Elapsed time.Duration  `json:"elapsed_ns"`
+	Assets  []*AssetResult `json:"assets"`
+	Skipped []string       `json:"skipped"`
+	Written []string       `json:"written"`
+	Stale   bool           `json:"stale,omitempty"`
+	Changes *AssetChanges  `json:"changes,omitempty"`
 }
 
-// String returns the pretty-print version of Result.
+// String returns the pretty-print version of Result.  Saved bytes are only
+// included when there were any.
 func (r *Result) String() string {
-	return fmt.Sprintf("files: %d, bytes: %d", r.Files, r.Bytes)
+	s := fmt.Sprintf("files: %d, bytes: %d", r.Files, r.Bytes)
+	if r.Saved > 0 {
+		s += fmt.Sprintf(", saved: %d", r.Saved)
+	}
+	return s
 }
 
 // GenData holds the data injected into the templates when generating files.
@@ -39,20 +69,44 @@ type GenData struct {
 	Package           string
 	Module            string
 	Names             []string
+	Index             []int
 	DataSums          []string
-	DataStrings       []string
+	DataStrings       <-chan string // streamed while rendering the data
+	DataVars          []string      // data tables in companion files, if split
+	DataVar           string        // data table in this companion file
+	MainFile          string        // code file for this companion file
+	Config            string        // recorded config, as JSON
 	ExistingAssetName string
 	ExistingAssetSum  string
 	MissingAssetName  string
 	AssetsEmpty       bool
+	Compat            bool     // generate go-bindata compatible functions
+	RootNames         []string // top-level names, as for AssetDir("")
+	DirNames          []string // all directories, with "" for the top level
+	Meta              bool     // record file modes and modification times
+	Modes             []uint32 // permission bits for each name, if Meta
+	ModTimes          []int64  // modification time for each name, if Meta
+	ExistingAssetMode uint32
+	ExistingAssetTime int64
+	FS                bool // generate AssetsFS and SubAssets
 }
 
 // Config holds the values used in Process, in order to avoid confusion.
 type Config struct {
-	Dir     string
-	Package string
-	File    string
-	Module  string
+	Dir       string
+	Package   string
+	File      string
+	Module    string
+	Jobs      int      // parallel workers for reading files; default is one per CPU
+	SplitSize int      // if positive, split data into files of about this size
+	Force     bool     // overwrite output files even if binsanity didn't make them
+	Check     bool     // only check whether the output files are up to date
+	DryRun    bool     // only read and compress the assets, writing nothing
+	Include   []string // if any, only assets matching one of these patterns
+	Exclude   []string // assets matching any of these patterns are left out
+	Compat    bool     // also generate go-bindata compatible functions
+	Meta      bool     // record file modes and modification times
+	FS        bool     // also generate an fs.FS view of the assets
 }
 
 // Process converts all files in cfg.Dir into readable data in a Go file
@@ -68,69 +122,112 @@ type Config struct {
 // The test file is named "binsanity_test.go" or the equivalent for the
 // code file, and provides full coverage of the generated functions.
 //
-// If either file exists it is overwritten.
+// Every generated file starts with GeneratedMarker.  The code file records
+// the effective configuration after it, for ReadConfig.  If either file exists
+// it is overwritten, but only if it was generated by binsanity: otherwise
+// ErrNotGenerated is returned, unless cfg.Force is set.  Both files are
+// rendered in full to temporary files in the same directory first, and only
+// renamed into place once everything has succeeded; on any error the
+// existing files are left untouched, or put back if renaming them fails
+// partway.  Files whose content would not change are never rewritten, so
+// their modification times stay the same.
+//
+// If cfg.SplitSize is positive, the asset data is written to numbered
+// companion files instead: "binsanity_data_001.go" and so on, each holding
+// about cfg.SplitSize bytes of data or a single larger asset.  The API and
+// the name table stay in the code file.  Companion files left over from
+// earlier runs are removed.
 //
 // Paths are stripped of their prefixes up to the dir and converted to
-// slash format when stored as asset names.
+// slash format when stored as asset names.  Anything other than a regular
+// file, such as a device or a link to a directory, is skipped.
+//
+// Assets are filtered by name with the cfg.Include and cfg.Exclude patterns,
+// as described for MatchName.
+//
+// If cfg.Compat is set, the code file also has the functions go-bindata
+// generates that binsanity otherwise doesn't, AssetInfo and AssetString, so
+// code written for go-bindata keeps working.
+//
+// If cfg.Meta is set, the permission bits and modification time of each
+// file are recorded, and used by RestoreAsset (and AssetInfo, if cfg.Compat
+// is set).  Otherwise the output doesn't change when only those do.
+//
+// If cfg.FS is set, the code file also has the AssetsFS type, a view of the
+// assets under a directory that is also an fs.FS, and SubAssets to get one.
+// They are left out otherwise, so they can't clash with the package's own
+// names.
+//
+// Identical content is stored only once: the name table points each name at
+// its data entry, so any number of names may share the same data.
 //
 // In the rare case of *no* assets found in the directory, a single special
-// asset is created in order to achieve test coverage.  Its name is randomized
-// and should not conflict with any real-world data as it begins with 256
+// placeholder asset is created in order to achieve test coverage.  It should
+// not conflict with any real-world data as its name begins with 256
 // underscores.
 //
 // This asset is *not* returned by the AssetNames function.
 //
-// The first error encountered is returned.
+// The output is a pure function of the assets and the configuration: the
+// same input always generates the same bytes.
+//
+// Files are read, summed and compressed by up to cfg.Jobs workers at once,
+// each in a single pass.  The output does not depend on the number of
+// workers.  Asset data is streamed from disk through compression and
+// encoding into the code file, and no more than MaxBuffered bytes of it are
+// held at once, however many workers there are.  An asset bigger than that
+// is encoded on its own, so memory use is bounded by MaxBuffered or the
+// largest asset, whichever is bigger, not the total.
+//
+// If cfg.Check is set, nothing is written: the output is rendered only to be
+// compared with the existing files, and the Result says whether they are
+// stale and how the assets have changed.
+//
+// If cfg.DryRun is set, the assets are read and compressed as usual but
+// nothing is rendered or written, and existing files are not looked at: the
+// Result describes what would be embedded.  The package and module are not
+// needed, so they are not guessed.  Setting both cfg.Check and cfg.DryRun is
+// an error, as a dry run can't tell whether anything is stale.
+//
+// The first error encountered is returned.  Once the configuration has been
+// checked, any error is a *ProcessError naming the stage and file involved.
 func Process(cfg *Config) (*Result, error) {
 
+	start := time.Now()
+
 	// must.. resist... edit-in-place... temptation... :-)
 	dir := cfg.Dir
-	mod := cfg.Module
-	pkg := cfg.Package
-	file := cfg.File
-
-	var err error
-	if dir == "" {
-		// Don't just hoover up whatever's here, it has to be explicit.
-		// (If you *want* do `binsanity /tmp` then fine, but say so.)
-		return nil, errors.New("Source file not specified.")
-	}
-	if file == "" {
-		file = "binsanity.go"
-	}
-	if filepath.Ext(file) != ".go" {
-		return nil, errors.New("Output must be to a .go file.")
-	}
-	if pkg == "" {
-		pkg, err = FindPackage(file)
-		if err != nil {
-			return nil, err
-		}
-	}
-	if mod == "" {
-		mod, err = FindImportPath(file)
-		if err != nil {
-			return nil, err
-		}
+	file, tfile, pkg, mod, err := checkConfig(cfg)
+	if err != nil {
+		return nil, err
 	}
 
 	info, err := os.Stat(dir)
 	if err != nil {
-		return nil, fmt.Errorf("Asset dir: %v", err)
+		return nil, &ProcessError{StageScan, dir, fmt.Errorf("Asset dir: %w", err)}
 	}
 	if !info.IsDir() {
 		// Any case where we want to allow a single file? Maybe.  It's a very
 		// bad practice though, so not supporting it unless asked.
-		return nil, fmt.Errorf("Not a directory: %s", dir)
+		return nil, &ProcessError{StageScan, dir, errors.New("Not a directory")}
 	}
 
 	// Grab filenames.
 	paths := []string{}
+	skipped := []string{}
+	infos := map[string]os.FileInfo{}
 	walker := func(path string, info os.FileInfo, err error) error {
 		if info == nil {
 			return nil
 		}
+		name := assetName(dir, path)
 		if info.IsDir() {
+			if path != dir && matchAny(cfg.Exclude, name) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !wanted(cfg, name) {
 			return nil
 		}
 
@@ -139,133 +236,299 @@ func Process(cfg *Config) (*Result, error) {
 		if err != nil {
 			return err
 		}
-		if realInfo.IsDir() {
+		if !realInfo.Mode().IsRegular() {
+			skipped = append(skipped, path)
 			return nil
 		}
 
 		paths = append(paths, path)
+		infos[path] = realInfo
 
 		return nil
 	}
 	err = filepath.Walk(dir, walker)
 	if err != nil {
-		return nil, fmt.Errorf("Error walking %s: %v", dir, err)
+		return nil, &ProcessError{StageScan, dir, err}
 	}
 	sort.Strings(paths)
+	sort.Strings(skipped)
 
 	// Get data for generating the files.
-	tfile := file[:len(file)-3] + "_test.go"
 	gen := &GenData{
-		CodeFile:    filepath.Base(file),
-		TestFile:    filepath.Base(tfile),
-		Package:     pkg,
-		Module:      mod,
-		Names:       make([]string, len(paths)),
-		DataSums:    make([]string, len(paths)),
-		DataStrings: make([]string, len(paths)),
-	}
-	total_bytes := 0
+		CodeFile: filepath.Base(file),
+		TestFile: filepath.Base(tfile),
+		Package:  pkg,
+		Module:   mod,
+		Names:    make([]string, len(paths)),
+		Config:   recordConfig(cfg, file, pkg, mod).String(),
+		Compat:   cfg.Compat,
+		Meta:     cfg.Meta,
+		FS:       cfg.FS,
+	}
+	sizes := make([]int, len(paths)) // as found, to estimate the data
 	for idx, path := range paths {
-		// name is cleaned version of path.
-		gen.Names[idx] = strings.TrimPrefix(
-			filepath.ToSlash(strings.TrimPrefix(path, dir)),
-			string(filepath.Separator),
-		)
-		b, err := os.ReadFile(path)
-		if err != nil {
-			return nil, fmt.Errorf("Error reading %s: %v", path, err)
+		gen.Names[idx] = assetName(dir, path)
+		sizes[idx] = int(infos[path].Size())
+		if cfg.Meta {
+			info := infos[path]
+			gen.Modes = append(gen.Modes, uint32(info.Mode().Perm()))
+			gen.ModTimes = append(gen.ModTimes, info.ModTime().Unix())
 		}
-		total_bytes += len(b)
-
-		// sum is of raw bytes.
-		gen.DataSums[idx] = fmt.Sprintf("%x", sha256.Sum256(b))
-
-		// data is compressed.
-		var buf bytes.Buffer
-		writer := gzip.NewWriter(&buf)
-
-		// TODO: (as a general task) figure out how to test this crap and
-		// write it up on a blog or something.  It will keep coming up, and
-		// it's non-idiomatic to ignore the error but also really not obvious
-		// how TF you are supposed to test it without doing some very weird
-		// gymnastics.  Presumably a "compress" function that takes an
-		// interface as its arg, right?  And you write an implementation that
-		// blows up, just for testing.  Yay.  Fuck.  So far so good BUT you
-		// still need to trap that error unless you make it panic... which I
-		// guess is legit in this case, but again not idiomatic... also maybe
-		// worth checking the gzip implementation and see if the writer here
-		// actually *can* return an error, right?
-		if _, err := writer.Write(b); err != nil {
-			return nil, fmt.Errorf("Error compressing asset %s: %v", path, err)
+
+	}
+	gen.RootNames = rootNames(gen.Names)
+	gen.DirNames = dirNames(gen.Names)
+
+	// The data itself is streamed into the code file as it is encoded, and
+	// the sums and data indexes are known once it has all been sent.  The
+	// code file has the indexes after the data, and the test file the sums.
+	enc := encodeFiles(paths, sizes, cfg.Jobs)
+	defer enc.Wait()
+	gen.DataStrings = enc.Data
+	gen.Index = enc.index
+	gen.DataSums = enc.sums
+
+	// A dry run only wants the sizes, so the data goes nowhere.
+	if cfg.DryRun {
+		for range enc.Data {
 		}
-		if err := writer.Close(); err != nil {
+		if err := enc.Wait(); err != nil {
 			return nil, err
 		}
-
-		gen.DataStrings[idx] = base64.StdEncoding.EncodeToString(buf.Bytes())
-
+		res := newResult(paths, gen, enc)
+		res.Skipped = skipped
+		res.Elapsed = time.Since(start)
+		return res, nil
 	}
 
 	// Special case for empty assets -- you might want to have an empty set
 	// of assets, but we still want test coverage.
 	if len(paths) == 0 {
 
-		b := make([]byte, 256)
-		rand.Read(b) // more untestable fun...
-
-		name := fmt.Sprintf("%s%x", strings.Repeat("_", 256), b)
+		dummy := make(chan string, 1)
+		dummy <- DummyDataString
+		close(dummy)
 
 		gen.AssetsEmpty = true
-		gen.Names = []string{name}
-		gen.DataStrings = []string{DummyDataString}
+		gen.Names = []string{placeholderName}
+		gen.Index = []int{0}
+		gen.DataStrings = dummy
 		gen.DataSums = []string{DummyDataSum}
 
 	}
 
-	// Create the code file.
-	ctmpl, err := template.New("t").Parse(MustAssetString("code.tmpl"))
-	// ctmpl, err := template.New("t").Parse(CTMP)
-	if err != nil {
-		panic(err) // testable how? probably not at all....
+	// Everything is rendered to temporary files first, and only put in
+	// place once all of it has worked.
+	out := &fileSet{check: cfg.Check}
+	defer out.Abort()
+
+	// Split data goes to the companion files first, so the code file knows
+	// what to join.
+	data_files := []string{}
+	if cfg.SplitSize > 0 {
+		data_files, err = writeDataFiles(out, file, gen, cfg.SplitSize)
+		if err != nil {
+			return nil, err
+		}
 	}
-	cwriter, err := os.Create(file)
+
+	// Render the code file.
+	ctmpl, err := template.New("t").Parse(CodeTemplate)
 	if err != nil {
+		return nil, &ProcessError{StageRender, "code.tmpl", err}
+	}
+	if err := out.Render(file, ctmpl, gen); err != nil {
 		return nil, err
 	}
-	defer cwriter.Close()
-	err = ctmpl.Execute(cwriter, gen)
-	if err != nil {
-		panic(err)
+	if err := enc.Wait(); err != nil {
+		return nil, err
 	}
 
 	// Some special sauce for the test file:
 	test_idx := int(len(gen.Names) / 2)
 	gen.ExistingAssetName = gen.Names[test_idx]
 	gen.ExistingAssetSum = gen.DataSums[test_idx]
+	if len(gen.ModTimes) > 0 {
+		gen.ExistingAssetMode = gen.Modes[test_idx]
+		gen.ExistingAssetTime = gen.ModTimes[test_idx]
+	}
 	gen.MissingAssetName = gen.Names[len(gen.Names)-1] + "--NOPE"
 
-	// Create the test file.
-	ttmpl, err := template.New("t").Parse(MustAssetString("tests.tmpl"))
-	// ttmpl, err := template.New("t").Parse(TTMP)
+	// Render the test file.
+	ttmpl, err := template.New("t").Parse(TestTemplate)
 	if err != nil {
-		panic(err) // testable????
+		return nil, &ProcessError{StageRender, "tests.tmpl", err}
 	}
-	twriter, err := os.Create(tfile)
-	if err != nil {
+	if err := out.Render(tfile, ttmpl, gen); err != nil {
 		return nil, err
 	}
-	defer twriter.Close()
-	err = ttmpl.Execute(twriter, gen)
-	if err != nil {
-		panic(err)
-	}
 
 	// Done... pending bug reports, of course, which are sort of inevitable
 	// for something this hastily written.
-	res := &Result{
-		Files: len(paths),
-		Bytes: total_bytes,
+	res := newResult(paths, gen, enc)
+	res.Skipped = skipped
+	res.Source = out.Size()
+
+	// If only checking, see what is different and leave it at that.
+	if cfg.Check {
+		stale, err := staleDataFiles(file, data_files)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, &ProcessError{StageWrite, file, err}
+		}
+		res.Stale = len(stale)+len(out.Differs()) > 0
+		res.Changes = checkChanges(file, gen)
+		res.Elapsed = time.Since(start)
+		return res, nil
 	}
+
+	// All good, so put it all in place.
+	res.Written, err = out.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	// Clean up after earlier runs with more, or any, companion files.
+	if err := removeStaleDataFiles(file, data_files); err != nil {
+		return nil, &ProcessError{StageWrite, file, err}
+	}
+
+	res.Elapsed = time.Since(start)
 	return res, nil
 
 }
+
+// checkConfig checks everything about cfg that can be checked without
+// reading the assets, and returns the code and test files to generate and
+// the package and module to generate them for.  Nothing is written.
+func checkConfig(cfg *Config) (file, tfile, pkg, mod string, err error) {
+
+	mod = cfg.Module
+	pkg = cfg.Package
+	file = cfg.File
+
+	if cfg.Dir == "" {
+		// Don't just hoover up whatever's here, it has to be explicit.
+		// (If you *want* do `binsanity /tmp` then fine, but say so.)
+		return "", "", "", "", errors.New("Source file not specified.")
+	}
+	if file == "" {
+		file = "binsanity.go"
+	}
+	if filepath.Ext(file) != ".go" {
+		return "", "", "", "", errors.New("Output must be to a .go file.")
+	}
+	if cfg.Check && cfg.DryRun {
+		return "", "", "", "", errors.New("Check and dry run can't be combined.")
+	}
+	if pkg == "" && !cfg.DryRun {
+		pkg, err = FindPackage(file)
+		if err != nil {
+			return "", "", "", "", err
+		}
+	}
+	if mod == "" && !cfg.DryRun {
+		mod, err = FindImportPath(file)
+		if err != nil {
+			return "", "", "", "", err
+		}
+	}
+
+	if err := checkPatterns(cfg.Include); err != nil {
+		return "", "", "", "", fmt.Errorf("Bad include pattern: %w", err)
+	}
+	if err := checkPatterns(cfg.Exclude); err != nil {
+		return "", "", "", "", fmt.Errorf("Bad exclude pattern: %w", err)
+	}
+
+	// Don't clobber anything we didn't make, unless told to.
+	tfile = TestFileName(file)
+	if !cfg.Force && !cfg.Check && !cfg.DryRun {
+		data_files, err := DataFiles(file)
+		if err != nil && !os.IsNotExist(err) {
+			return "", "", "", "", &ProcessError{StageWrite, file, err}
+		}
+		if err := checkOverwrite(append([]string{file, tfile}, data_files...)...); err != nil {
+			return "", "", "", "", err
+		}
+	}
+	return file, tfile, pkg, mod, nil
+
+}
+
+// newResult returns the Result for the files at paths once they have all
+// been encoded.
+func newResult(paths []string, gen *GenData, enc *encoding) *Result {
+
+	res := &Result{
+		Files:  len(paths),
+		Assets: make([]*AssetResult, len(paths)),
+	}
+	for idx, path := range paths {
+		didx := gen.Index[idx]
+		res.Bytes += enc.sizes[idx]
+		res.Assets[idx] = &AssetResult{
+			Name:   gen.Names[idx],
+			Path:   path,
+			Size:   enc.sizes[idx],
+			Stored: enc.stored[didx],
+			Codec:  Codec,
+			Sum:    enc.sums[idx],
+		}
+
+		// Anything not stored itself is saved.
+		if path == enc.unique[didx] {
+			res.Stored += enc.stored[didx]
+		} else {
+			res.Saved += base64.StdEncoding.EncodedLen(enc.stored[didx])
+		}
+	}
+	return res
+
+}
+
+// assetName returns the name of the asset at path in dir: the path relative
+// to dir, with forward slashes.  Walk cleans the paths it visits, so dir is
+// compared the same way, and "./assets" works like "assets".
+func assetName(dir, path string) string {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		// Can't happen for paths found under dir.
+		rel = path
+	}
+	return filepath.ToSlash(rel)
+}
+
+// rootNames returns the sorted, distinct first elements of the asset names:
+// the assets and directories at the top level.
+func rootNames(names []string) []string {
+
+	roots := []string{}
+	seen := map[string]bool{}
+	for _, name := range names {
+		root := strings.SplitN(name, "/", 2)[0]
+		if !seen[root] {
+			seen[root] = true
+			roots = append(roots, root)
+		}
+	}
+	sort.Strings(roots)
+	return roots
+
+}
+
+// dirNames returns the sorted names of the directories containing the named
+// assets, with "" for the top level.
+func dirNames(names []string) []string {
+
+	dirs := []string{""}
+	seen := map[string]bool{"": true}
+	for _, name := range names {
+		for dir := path.Dir(name); dir != "." && !seen[dir]; dir = path.Dir(dir) {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+	sort.Strings(dirs)
+	return dirs
+
+}
@@ -2,9 +2,17 @@
 package binsanity_test
 
 import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -94,3 +102,433 @@ func TestProcessErrFindImportPath(t *testing.T) {
 	assert.ErrorContains(err, "No go.mod file found.")
 
 }
+
+func TestResultStringSaved(t *testing.T) {
+
+	assert := assert.New(t)
+
+	res := &binsanity.Result{
+		Files: 12,
+		Bytes: 34,
+		Saved: 56,
+	}
+	assert.Equal("files: 12, bytes: 34, saved: 56", res.String())
+
+}
+
+func TestProcessOkDedup(t *testing.T) {
+
+	assert := assert.New(t)
+
+	// Same content under several names should be stored once.
+	tdir := t.TempDir()
+	adir := filepath.Join(tdir, "assets")
+	WriteTree(t, adir, map[string]string{
+		"a":   "same same",
+		"b":   "same same",
+		"c/d": "same same",
+	})
+	cfg := &binsanity.Config{
+		Dir:     adir,
+		File:    filepath.Join(tdir, "binsanity.go"),
+		Package: "foo",
+		Module:  "example.com/foo",
+	}
+	res, err := binsanity.Process(cfg)
+	if !assert.Nil(err, "no error") {
+		return
+	}
+	assert.Equal(3, res.Files, "files")
+	assert.Equal(27, res.Bytes, "bytes")
+	assert.True(res.Saved > 0, "saved something")
+
+	b, err := os.ReadFile(cfg.File)
+	if !assert.Nil(err, "read code file") {
+		return
+	}
+	assert.Contains(string(b), "var binsanity_index = []int{\n\t0,\n\t0,\n\t0,\n}")
+	assert.Equal(1, strings.Count(string(b), `"H4sI`), "one data entry")
+
+}
+
+func TestProcessOkEmpty(t *testing.T) {
+
+	assert := assert.New(t)
+
+	tdir := t.TempDir()
+	adir := filepath.Join(tdir, "assets")
+	if err := os.Mkdir(adir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &binsanity.Config{
+		Dir:     adir,
+		File:    filepath.Join(tdir, "binsanity.go"),
+		Package: "foo",
+		Module:  "example.com/foo",
+	}
+	res, err := binsanity.Process(cfg)
+	if assert.Nil(err, "no error") {
+		assert.Equal("files: 0, bytes: 0", res.String())
+	}
+	assert.FileExists(cfg.File)
+
+}
+
+func TestProcessOkDryRun(t *testing.T) {
+
+	assert := assert.New(t)
+
+	// Even a file we would refuse to overwrite is fine, as it's not touched.
+	tdir := t.TempDir()
+	WriteTree(t, tdir, map[string]string{"binsanity.go": "package foo\n"})
+	cfg := &binsanity.Config{
+		Dir:     ExampleAssetDir,
+		File:    filepath.Join(tdir, "binsanity.go"),
+		Package: "foo",
+		Module:  "example.com/foo",
+		DryRun:  true,
+	}
+	res, err := binsanity.Process(cfg)
+	if !assert.Nil(err, "no error") {
+		return
+	}
+	assert.Equal("files: 4, bytes: 58, saved: 52", res.String())
+	assert.Equal(0, res.Source, "no source")
+	if assert.Equal(4, len(res.Assets), "assets") {
+		a := res.Assets[1]
+		assert.Equal("baz/bat/bloopf", a.Name, "name")
+		assert.Equal(22, a.Size, "size")
+		assert.True(a.Stored > 0, "stored")
+		assert.Equal("4fed59a4fcf97d28970b4a46c5eb52fc6ce9336fab8248073a6ba7c7b49aef59",
+			a.Sum, "sum")
+		assert.Equal(res.Assets[2].Stored, res.Assets[3].Stored, "dupes")
+	}
+
+	b, err := os.ReadFile(cfg.File)
+	if assert.Nil(err, "read") {
+		assert.Equal("package foo\n", string(b), "untouched")
+	}
+	assert.NoFileExists(filepath.Join(tdir, "binsanity_test.go"))
+
+	// A dry run can't check anything.
+	cfg.Check = true
+	_, err = binsanity.Process(cfg)
+	assert.EqualError(err, "Check and dry run can't be combined.", "with check")
+
+}
+
+func TestProcessOkUncleanDir(t *testing.T) {
+
+	assert := assert.New(t)
+
+	// Walk cleans the paths, so the names must not depend on how the dir
+	// was written.
+	sep := string(filepath.Separator)
+	for _, dir := range []string{
+		"." + sep + ExampleAssetDir,
+		ExampleAssetDir + sep,
+		ExampleDir + sep + "sub" + sep + ".." + sep + "assets",
+	} {
+		res, err := binsanity.Process(&binsanity.Config{
+			Dir:     dir,
+			File:    filepath.Join(t.TempDir(), "binsanity.go"),
+			Package: "foo",
+			Module:  "example.com/foo",
+			DryRun:  true,
+			Exclude: []string{"baz/bat"},
+		})
+		if !assert.Nil(err, "no error for %s", dir) {
+			continue
+		}
+		names := []string{}
+		for _, a := range res.Assets {
+			names = append(names, a.Name)
+		}
+		assert.Equal([]string{"bar", "baz/foo", "foo"}, names, "names for %s", dir)
+	}
+
+}
+
+func TestProcessErrDryRunCompress(t *testing.T) {
+
+	assert := assert.New(t)
+
+	binsanity.NewCompressor = func(w io.Writer) io.WriteCloser {
+		return &failWriter{}
+	}
+	defer RestoreDefaults()
+
+	tdir := t.TempDir()
+	cfg := &binsanity.Config{
+		Dir:     ExampleAssetDir,
+		File:    filepath.Join(tdir, "binsanity.go"),
+		Package: "foo",
+		Module:  "example.com/foo",
+		DryRun:  true,
+	}
+	_, err := binsanity.Process(cfg)
+	var perr *binsanity.ProcessError
+	if assert.ErrorAs(err, &perr) {
+		assert.Equal(binsanity.StageCompress, perr.Stage, "stage")
+	}
+
+}
+
+func TestProcessOkResultDetails(t *testing.T) {
+
+	assert := assert.New(t)
+
+	tdir := t.TempDir()
+	adir := filepath.Join(tdir, "assets")
+	WriteTree(t, adir, map[string]string{
+		"a":     "same same",
+		"b/c":   "same same",
+		"b/d/e": "different",
+	})
+	if err := os.Symlink(filepath.Join(adir, "b"), filepath.Join(adir, "link")); err != nil {
+		t.Skip("no symlinks here:", err)
+	}
+	cfg := &binsanity.Config{
+		Dir:     adir,
+		File:    filepath.Join(tdir, "binsanity.go"),
+		Package: "foo",
+		Module:  "example.com/foo",
+	}
+	res, err := binsanity.Process(cfg)
+	if !assert.Nil(err, "no error") {
+		return
+	}
+	assert.Equal(3, res.Files, "files")
+	assert.Equal([]string{filepath.Join(adir, "link")}, res.Skipped, "skipped")
+	if assert.Equal(3, len(res.Assets), "assets") {
+		a := res.Assets[1]
+		assert.Equal("b/c", a.Name, "name")
+		assert.Equal(filepath.Join(adir, "b", "c"), a.Path, "path")
+		assert.Equal(binsanity.Codec, a.Codec, "codec")
+		assert.Equal(res.Assets[0].Stored+res.Assets[2].Stored, res.Stored,
+			"stored counts shared data once")
+	}
+	assert.True(res.Elapsed > 0, "elapsed")
+
+	code, err := os.Stat(cfg.File)
+	if !assert.Nil(err, "stat code") {
+		return
+	}
+	tests, err := os.Stat(filepath.Join(tdir, "binsanity_test.go"))
+	if !assert.Nil(err, "stat tests") {
+		return
+	}
+	assert.Equal(int(code.Size()+tests.Size()), res.Source, "source")
+
+}
+
+func TestProcessReproducible(t *testing.T) {
+
+	empty := t.TempDir()
+	many := t.TempDir()
+	WriteTree(t, many, manyFiles())
+	generate := func(cfg binsanity.Config) map[string]string {
+		dir := t.TempDir()
+		cfg.File = filepath.Join(dir, "binsanity.go")
+		cfg.Package = "foo"
+		cfg.Module = "example.com/foo"
+		if _, err := binsanity.Process(&cfg); err != nil {
+			t.Fatal(err)
+		}
+		return readAll(t, dir)
+	}
+
+	// Each config is generated twice, and again with a different number of
+	// workers: all must match byte for byte.
+	for name, cfg := range map[string]binsanity.Config{
+		"empty":   {Dir: empty},
+		"example": {Dir: ExampleAssetDir},
+		"split":   {Dir: ExampleAssetDir, SplitSize: 50},
+		"many":    {Dir: many, SplitSize: 1000},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			cfg.Jobs = 1
+			first := generate(cfg)
+			assert.Equal(first, generate(cfg), "same again")
+			cfg.Jobs = 8
+			assert.Equal(first, generate(cfg), "same with more workers")
+		})
+	}
+
+}
+
+func TestProcessCompat(t *testing.T) {
+
+	assert := assert.New(t)
+
+	empty := t.TempDir()
+	generate := func(dir string, compat bool) string {
+		out := t.TempDir()
+		_, err := binsanity.Process(&binsanity.Config{
+			Dir:     dir,
+			File:    filepath.Join(out, "binsanity.go"),
+			Package: "foo",
+			Module:  "example.com/foo",
+			Compat:  compat,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		files := readAll(t, out)
+		return files["binsanity.go"] + files["binsanity_test.go"]
+	}
+
+	src := generate(ExampleAssetDir, false)
+	assert.NotContains(src, "func AssetString(", "not by default")
+	assert.NotContains(src, "binsanity_canonical", "names as given by default")
+	assert.NotContains(src, "func TestAssetInfo(", "no tests by default")
+	assert.Contains(src, "func RestoreAssets(dir, prefix string, opts ...RestoreOption) error {",
+		"restore always")
+
+	src = generate(ExampleAssetDir, true)
+	for _, s := range []string{
+		"func AssetString(name string) (string, error) {",
+		"func AssetInfo(name string) (os.FileInfo, error) {",
+		"func TestAssetString(t *testing.T) {",
+		"func TestAssetInfo(t *testing.T) {",
+	} {
+		assert.Contains(src, s, "compat")
+	}
+
+	src = generate(empty, true)
+	assert.Contains(src, "func AssetInfo(name string) (os.FileInfo, error) {", "compat when empty")
+	assert.NotContains(src, "binsanity_asset_info", "simpler when empty")
+
+}
+
+func TestProcessNavigation(t *testing.T) {
+
+	assert := assert.New(t)
+
+	generate := func(fs bool) map[string]string {
+		out := t.TempDir()
+		_, err := binsanity.Process(&binsanity.Config{
+			Dir:     ExampleAssetDir,
+			File:    filepath.Join(out, "binsanity.go"),
+			Package: "foo",
+			Module:  "example.com/foo",
+			FS:      fs,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return readAll(t, out)
+	}
+	files := generate(false)
+	for _, s := range []string{
+		"func AssetDir(name string) ([]string, error) {",
+		"func WalkAssets(root string, fn func(name string, dir bool) error) error {",
+		"func GlobAssets(pattern string) ([]string, error) {",
+		"func binsanity_prefixed(prefix string) []string {",
+	} {
+		assert.Contains(files["binsanity.go"], s, "code")
+	}
+	for _, s := range []string{
+		"var BinsanityAssetRoot = []string{\n\t\"bar\",\n\t\"baz\",\n\t\"foo\",\n}",
+		"var BinsanityAssetDirs = []string{\n\t\"\",\n\t\"baz\",\n\t\"baz/bat\",\n}",
+		"func TestWalkAssets(t *testing.T) {",
+		"func TestGlobAssets(t *testing.T) {",
+	} {
+		assert.Contains(files["binsanity_test.go"], s, "tests")
+	}
+
+	// The fs.FS view only on request, so as not to take its names otherwise.
+	assert.NotContains(files["binsanity.go"], "AssetsFS", "no fs")
+	assert.NotContains(files["binsanity.go"], "func Sub", "no fs")
+	assert.NotContains(files["binsanity_test.go"], "SubAssets", "no fs tests")
+	files = generate(true)
+	for _, s := range []string{
+		"func SubAssets(dir string) (*AssetsFS, error) {",
+		"func (a *AssetsFS) Open(name string) (fs.File, error) {",
+		"func (a *AssetsFS) Sub(dir string) (*AssetsFS, error) {",
+	} {
+		assert.Contains(files["binsanity.go"], s, "fs code")
+	}
+	assert.Contains(files["binsanity_test.go"], "func TestSubAssets(t *testing.T) {",
+		"fs tests")
+
+}
+
+func TestProcessAssetsDeclared(t *testing.T) {
+
+	assert := assert.New(t)
+
+	// Names common in packages with assets are left alone by default.
+	out := t.TempDir()
+	WriteTree(t, out, map[string]string{
+		"assets.go": "package foo\n\n" +
+			"type Assets struct{}\n\n" +
+			"func Sub(dir string) *Assets { return nil }\n",
+	})
+	_, err := binsanity.Process(&binsanity.Config{
+		Dir:     ExampleAssetDir,
+		File:    filepath.Join(out, "binsanity.go"),
+		Package: "foo",
+		Module:  "example.com/foo",
+	})
+	if !assert.Nil(err, "no error") {
+		return
+	}
+	fset := token.NewFileSet()
+	files := []*ast.File{}
+	for _, name := range []string{"assets.go", "binsanity.go"} {
+		f, err := parser.ParseFile(fset, filepath.Join(out, name), nil, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, f)
+	}
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	_, err = conf.Check("example.com/foo", fset, files, nil)
+	assert.Nil(err, "package compiles")
+
+}
+
+func TestProcessMeta(t *testing.T) {
+
+	assert := assert.New(t)
+
+	tdir := t.TempDir()
+	WriteTree(t, tdir, map[string]string{"a": "A", "b/c": "C"})
+	mtime := time.Unix(1234567890, 0)
+	if err := os.Chtimes(filepath.Join(tdir, "b", "c"), mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(filepath.Join(tdir, "b", "c"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	generate := func(meta bool) string {
+		out := t.TempDir()
+		_, err := binsanity.Process(&binsanity.Config{
+			Dir:     tdir,
+			File:    filepath.Join(out, "binsanity.go"),
+			Package: "foo",
+			Module:  "example.com/foo",
+			Meta:    meta,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		files := readAll(t, out)
+		return files["binsanity.go"] + files["binsanity_test.go"]
+	}
+
+	src := generate(false)
+	assert.NotContains(src, "binsanity_modes", "not by default")
+	assert.Contains(src, "return os.WriteFile(file, b, 0644)", "default mode")
+
+	src = generate(true)
+	assert.Contains(src, "var binsanity_modes = []os.FileMode{\n\t0644,\n\t0600,\n}", "modes")
+	assert.Contains(src, "\t1234567890,\n}", "mtimes")
+	assert.Contains(src, "const BinsanityAssetPresentMode = 0600\n", "test mode")
+	assert.Contains(src, "const BinsanityAssetPresentTime = 1234567890\n", "test mtime")
+	assert.Contains(src, "return binsanity_write(file, b, mode, mtime)", "restored")
+	assert.Contains(src, "err = os.Chmod(tmp.Name(), mode)", "mode set")
+
+}
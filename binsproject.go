@@ -0,0 +1,107 @@
+// binsproject.go -- binsanity project config files with multiple jobs.
+
+package binsanity
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ProjectFile is the name of the project config file used by default, when
+// binsanity is run without arguments.
+const ProjectFile = "binsanity.json"
+
+// Project holds the jobs in a project config file, each generating one set
+// of files.
+type Project struct {
+	File string `json:"-"` // the config file read
+	Jobs []*Job `json:"jobs"`
+}
+
+// Job is a single job in a project config file.  Its Dir and Output are
+// relative to the directory of the config file, and Output defaults to
+// "binsanity.go" there.  Package and Module are guessed if empty, as in
+// Process.
+type Job struct {
+	Dir       string   `json:"dir"`
+	Output    string   `json:"output"`
+	Package   string   `json:"package"`
+	Module    string   `json:"module"`
+	Include   []string `json:"include"`
+	Exclude   []string `json:"exclude"`
+	SplitSize int      `json:"split_size"`
+	Compat    bool     `json:"compat"`
+	Meta      bool     `json:"meta"`
+	FS        bool     `json:"fs"`
+}
+
+// JobResult is the Result of one job in a project, with its output file.
+type JobResult struct {
+	File string `json:"file"`
+	*Result
+}
+
+// ReadProject reads the project config file at file, which must be JSON
+// with at least one job, each with a dir.  Unknown fields are an error, to
+// catch typos.
+func ReadProject(file string) (*Project, error) {
+
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	proj := &Project{File: file}
+	if err := dec.Decode(proj); err != nil {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+	if len(proj.Jobs) == 0 {
+		return nil, fmt.Errorf("%s: No jobs.", file)
+	}
+	for idx, job := range proj.Jobs {
+		if job == nil || job.Dir == "" {
+			return nil, fmt.Errorf("%s: job %d: Source dir not specified.",
+				file, idx+1)
+		}
+	}
+	return proj, nil
+
+}
+
+// Config returns the Config for the job in the project, with its paths
+// resolved.
+func (p *Project) Config(job *Job) *Config {
+
+	base := filepath.Dir(p.File)
+	output := job.Output
+	if output == "" {
+		output = "binsanity.go"
+	}
+	return &Config{
+		Dir:       resolvePath(base, job.Dir),
+		File:      resolvePath(base, output),
+		Package:   job.Package,
+		Module:    job.Module,
+		Include:   job.Include,
+		Exclude:   job.Exclude,
+		SplitSize: job.SplitSize,
+		Compat:    job.Compat,
+		Meta:      job.Meta,
+		FS:        job.FS,
+	}
+
+}
+
+// resolvePath returns the slash-separated path p relative to base, unless
+// it is absolute.
+func resolvePath(base, p string) string {
+	p = filepath.FromSlash(p)
+	if filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(base, p)
+}
@@ -0,0 +1,243 @@
+// binsproject_test.go - tests for stuff in binsproject.go
+package binsanity_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/biztos/binsanity"
+)
+
+// projectTree writes a project with two asset dirs and a config file for
+// them to dir, and returns the config file.
+func projectTree(t *testing.T, dir string) string {
+
+	WriteTree(t, dir, map[string]string{
+		"go.mod":          "module example.com/proj\n",
+		"web/doc.go":      "package web\n",
+		"web/assets/a":    "aaa",
+		"web/assets/a~":   "backup",
+		"db/sql/init.sql": "select 1;",
+		"binsanity.json": `{"jobs": [
+			{"dir": "web/assets", "output": "web/binsanity.go", "exclude": ["*~"]},
+			{"dir": "db/sql", "output": "db/sql.go", "package": "db",
+			 "module": "example.com/proj/db", "split_size": 1000, "compat": true,
+			 "meta": true, "fs": true}
+		]}`,
+	})
+	return filepath.Join(dir, "binsanity.json")
+
+}
+
+func TestReadProject(t *testing.T) {
+
+	assert := assert.New(t)
+
+	tdir := t.TempDir()
+	file := projectTree(t, tdir)
+	proj, err := binsanity.ReadProject(file)
+	if !assert.Nil(err, "no error") {
+		return
+	}
+	if !assert.Equal(2, len(proj.Jobs), "jobs") {
+		return
+	}
+	assert.Equal(&binsanity.Config{
+		Dir:     filepath.Join(tdir, "web", "assets"),
+		File:    filepath.Join(tdir, "web", "binsanity.go"),
+		Exclude: []string{"*~"},
+	}, proj.Config(proj.Jobs[0]), "first")
+	assert.Equal(&binsanity.Config{
+		Dir:       filepath.Join(tdir, "db", "sql"),
+		File:      filepath.Join(tdir, "db", "sql.go"),
+		Package:   "db",
+		Module:    "example.com/proj/db",
+		SplitSize: 1000,
+		Compat:    true,
+		Meta:      true,
+		FS:        true,
+	}, proj.Config(proj.Jobs[1]), "second")
+
+	// Output defaults, and absolute paths stay put.
+	abs := filepath.Join(tdir, "x")
+	job := &binsanity.Job{Dir: filepath.ToSlash(abs)}
+	cfg := proj.Config(job)
+	assert.Equal(abs, cfg.Dir, "absolute")
+	assert.Equal(filepath.Join(tdir, "binsanity.go"), cfg.File, "default output")
+
+}
+
+func TestReadProjectErrors(t *testing.T) {
+
+	assert := assert.New(t)
+
+	tdir := t.TempDir()
+	WriteTree(t, tdir, map[string]string{
+		"bad.json":     `{"jobs": [`,
+		"typo.json":    `{"jobs": [{"dir": "a", "ouptut": "x.go"}]}`,
+		"empty.json":   `{"jobs": []}`,
+		"nodir.json":   `{"jobs": [{"dir": "a"}, {"output": "x.go"}]}`,
+		"nulljob.json": `{"jobs": [null]}`,
+	})
+	path := func(name string) string { return filepath.Join(tdir, name) }
+
+	_, err := binsanity.ReadProject(path("nope.json"))
+	assert.True(os.IsNotExist(err), "missing")
+	_, err = binsanity.ReadProject(path("bad.json"))
+	assert.EqualError(err, path("bad.json")+": unexpected EOF")
+	_, err = binsanity.ReadProject(path("typo.json"))
+	assert.EqualError(err, path("typo.json")+`: json: unknown field "ouptut"`)
+	_, err = binsanity.ReadProject(path("empty.json"))
+	assert.EqualError(err, path("empty.json")+": No jobs.")
+	_, err = binsanity.ReadProject(path("nodir.json"))
+	assert.EqualError(err, path("nodir.json")+": job 2: Source dir not specified.")
+	_, err = binsanity.ReadProject(path("nulljob.json"))
+	assert.EqualError(err, path("nulljob.json")+": job 1: Source dir not specified.")
+
+}
+
+func TestRunAppProject(t *testing.T) {
+
+	assert := assert.New(t)
+
+	tdir := t.TempDir()
+	file := projectTree(t, tdir)
+
+	exit_code := 0
+	binsanity.ExitFunc = func(c int) { exit_code = c }
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	binsanity.OutWriter = stdout
+	binsanity.ErrWriter = stderr
+	defer RestoreDefaults()
+
+	binsanity.RunApp([]string{"appname", "-c", file})
+	assert.Equal(0, exit_code, "exit 0")
+	assert.Equal(
+		filepath.Join(tdir, "web", "binsanity.go")+": files: 1, bytes: 3\n"+
+			filepath.Join(tdir, "db", "sql.go")+": files: 1, bytes: 9\n",
+		stdout.String(), "stdout")
+	assert.Equal("", stderr.String(), "stderr")
+	assert.FileExists(filepath.Join(tdir, "db", "sql_data_001.go"), "split")
+
+	rec, err := binsanity.ReadConfig(filepath.Join(tdir, "web", "binsanity.go"))
+	if assert.Nil(err, "read") {
+		assert.Equal("web", rec.Package, "package found")
+		assert.Equal("example.com/proj/web", rec.Module, "module found")
+		assert.Equal([]string{"*~"}, rec.Exclude, "exclude")
+	}
+
+	// A bare run in the dir finds the config file.
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(tdir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	stdout.Reset()
+	binsanity.RunApp([]string{"appname", "--check", "--json"})
+	assert.Equal(0, exit_code, "exit 0")
+	results := []*binsanity.JobResult{}
+	if assert.Nil(json.Unmarshal(stdout.Bytes(), &results), "json") &&
+		assert.Equal(2, len(results), "results") {
+		assert.Equal(filepath.Join("db", "sql.go"), results[1].File, "file")
+		assert.Equal(1, results[1].Files, "files")
+		assert.False(results[1].Stale, "not stale")
+	}
+
+	// No ASSET_DIR with a config file.
+	stdout.Reset()
+	binsanity.RunApp([]string{"appname", "-c", "binsanity.json", "web/assets"})
+	assert.Equal(1, exit_code, "exit 1")
+	assert.Equal("No ASSET_DIR allowed with a config file.\n", stderr.String(), "stderr")
+
+	// Nor any options for what to generate, which would be ignored.
+	for opt, name := range map[string]string{
+		"--output=x.go":   "output",
+		"-o=x.go":         "output",
+		"--package=x":     "package",
+		"-p=x":            "package",
+		"--module=x":      "module",
+		"--split-size=10": "split-size",
+		"--include=*":     "include",
+		"--exclude=*":     "exclude",
+		"--compat":        "compat",
+		"--meta":          "meta",
+		"--fs":            "fs",
+	} {
+		exit_code = 0
+		stderr.Reset()
+		binsanity.RunApp([]string{"appname", opt})
+		assert.Equal(1, exit_code, "exit 1 for %s", opt)
+		assert.Equal("No --"+name+" allowed with a config file.\n", stderr.String(),
+			"stderr for %s", opt)
+	}
+
+}
+
+func TestRunAppProjectFails(t *testing.T) {
+
+	assert := assert.New(t)
+
+	tdir := t.TempDir()
+	file := projectTree(t, tdir)
+	WriteTree(t, tdir, map[string]string{
+		"web/binsanity.go": "package web\n", // not ours
+	})
+
+	exit_code := 0
+	binsanity.ExitFunc = func(c int) { exit_code = c }
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	binsanity.OutWriter = stdout
+	binsanity.ErrWriter = stderr
+	defer RestoreDefaults()
+
+	// The first job fails, so the second doesn't run.
+	code := filepath.Join(tdir, "web", "binsanity.go")
+	binsanity.RunApp([]string{"appname", "--config=" + file})
+	assert.Equal(1, exit_code, "exit 1")
+	assert.Equal("", stdout.String(), "stdout")
+	assert.Equal("Job 1 ("+code+"): write "+code+": "+
+		binsanity.ErrNotGenerated.Error()+"\n", stderr.String(), "stderr")
+	assert.NoFileExists(filepath.Join(tdir, "db", "sql.go"))
+
+	// Checking, the first job is just stale, which stops the run too.
+	exit_code = 0
+	stdout.Reset()
+	stderr.Reset()
+	binsanity.RunApp([]string{"appname", "--config=" + file, "--check", "--json"})
+	assert.Equal(1, exit_code, "exit 1")
+	results := []*binsanity.JobResult{}
+	if assert.Nil(json.Unmarshal(stdout.Bytes(), &results), "json") &&
+		assert.Equal(1, len(results), "results") {
+		assert.True(results[0].Stale, "stale")
+	}
+	assert.Equal("Job 1 ("+code+"): Generated files are out of date.\n",
+		stderr.String(), "stderr")
+
+	// Nor can the results be printed.
+	exit_code = 0
+	stderr.Reset()
+	binsanity.OutWriter = &failWriter{}
+	binsanity.RunApp([]string{"appname", "--config=" + file, "--check", "--json"})
+	binsanity.OutWriter = stdout
+	assert.Equal(1, exit_code, "exit 1")
+	assert.Equal("Job 1 ("+code+"): Generated files are out of date. "+
+		"(and printing results: write failed)\n", stderr.String(), "stderr")
+
+	// Bad config.
+	exit_code = 0
+	stderr.Reset()
+	binsanity.RunApp([]string{"appname", "-c", filepath.Join(tdir, "nope.json")})
+	assert.Equal(1, exit_code, "exit 1")
+	assert.Contains(stderr.String(), "nope.json", "stderr")
+
+}
@@ -0,0 +1,265 @@
+// binsread.go -- binsanity reading of previously generated files.
+
+package binsanity
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// placeholderPrefix starts the name of the single asset generated for an
+// empty asset dir, 256 underscores.  It is not a real asset.
+const placeholderPrefix = "" +
+	"________________________________________________________________" +
+	"________________________________________________________________" +
+	"________________________________________________________________" +
+	"________________________________________________________________"
+
+// placeholderName is the name of that asset.  It is fixed, so that the output
+// for an empty asset dir is as reproducible as any other.
+const placeholderName = placeholderPrefix + "binsanity-empty"
+
+// Generated holds the assets found in a generated code file by
+// ReadGenerated.
+type Generated struct {
+	File    string   // the code file read
+	Package string   // its package
+	Names   []string // sorted asset names, without any placeholder
+	Index   []int    // data index for each name
+	Data    []string // encoded data entries
+}
+
+// ReadGenerated parses the code file generated by binsanity at file, and
+// any companion data files, and returns the asset names and data found.
+//
+// Files from older versions of binsanity, without a data index, are read as
+// well: their data entries simply match up with their names.
+func ReadGenerated(file string) (*Generated, error) {
+
+	vars, pkg, err := parseVars(file)
+	if err != nil {
+		return nil, err
+	}
+
+	gen := &Generated{File: file, Package: pkg}
+	names, err := stringList(vars["binsanity_names"])
+	if err != nil {
+		return nil, fmt.Errorf("%s: binsanity_names: %v", file, err)
+	}
+
+	// Older versions had one data entry per name, in the same order.
+	index := make([]int, len(names))
+	for idx := range index {
+		index[idx] = idx
+	}
+	if expr := vars["binsanity_index"]; expr != nil {
+		index, err = intList(expr)
+		if err != nil {
+			return nil, fmt.Errorf("%s: binsanity_index: %v", file, err)
+		}
+	}
+
+	// Data may be in the file itself, or joined from companion files.
+	expr := vars["binsanity_data"]
+	if call, ok := expr.(*ast.CallExpr); ok {
+		gen.Data, err = readDataFiles(file, call)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		gen.Data, err = stringList(expr)
+		if err != nil {
+			return nil, fmt.Errorf("%s: binsanity_data: %v", file, err)
+		}
+	}
+
+	if len(index) != len(names) {
+		return nil, fmt.Errorf("%s: %d names but %d index entries",
+			file, len(names), len(index))
+	}
+	for idx, name := range names {
+		if index[idx] < 0 || index[idx] >= len(gen.Data) {
+			return nil, fmt.Errorf("%s: no data for %s", file, name)
+		}
+		if strings.HasPrefix(name, placeholderPrefix) {
+			continue
+		}
+		gen.Names = append(gen.Names, name)
+		gen.Index = append(gen.Index, index[idx])
+	}
+	return gen, nil
+
+}
+
+// parseVars parses the Go source at file and returns the values of its
+// top-level vars by name, and its package name.
+func parseVars(file string) (map[string]ast.Expr, string, error) {
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, file, nil, 0)
+	if err != nil {
+		return nil, "", err
+	}
+	vars := map[string]ast.Expr{}
+	for _, decl := range f.Decls {
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok || gd.Tok != token.VAR {
+			continue
+		}
+		for _, spec := range gd.Specs {
+			vs := spec.(*ast.ValueSpec)
+			for idx, name := range vs.Names {
+				if idx < len(vs.Values) {
+					vars[name.Name] = vs.Values[idx]
+				}
+			}
+		}
+	}
+	return vars, f.Name.Name, nil
+
+}
+
+// readDataFiles returns the data joined by call from the companion data
+// files of file.
+func readDataFiles(file string, call *ast.CallExpr) ([]string, error) {
+
+	paths, err := DataFiles(file)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+	vars := map[string]ast.Expr{}
+	for _, path := range paths {
+		dvars, _, err := parseVars(path)
+		if err != nil {
+			return nil, err
+		}
+		for name, expr := range dvars {
+			vars[name] = expr
+		}
+	}
+
+	data := []string{}
+	for _, arg := range call.Args {
+		ident, ok := arg.(*ast.Ident)
+		if !ok {
+			return nil, fmt.Errorf("%s: binsanity_data: not a data table", file)
+		}
+		part, err := stringList(vars[ident.Name])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s: %v", file, ident.Name, err)
+		}
+		data = append(data, part...)
+	}
+	return data, nil
+
+}
+
+// stringList returns the values of a composite literal of strings.
+func stringList(expr ast.Expr) ([]string, error) {
+
+	lit, ok := expr.(*ast.CompositeLit)
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	list := make([]string, len(lit.Elts))
+	for idx, elt := range lit.Elts {
+		bl, ok := elt.(*ast.BasicLit)
+		if !ok || bl.Kind != token.STRING {
+			return nil, fmt.Errorf("item %d not a string", idx)
+		}
+		s, err := strconv.Unquote(bl.Value)
+		if err != nil {
+			return nil, fmt.Errorf("item %d: %v", idx, err)
+		}
+		list[idx] = s
+	}
+	return list, nil
+
+}
+
+// intList returns the values of a composite literal of ints.
+func intList(expr ast.Expr) ([]int, error) {
+
+	lit, ok := expr.(*ast.CompositeLit)
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	list := make([]int, len(lit.Elts))
+	for idx, elt := range lit.Elts {
+		bl, ok := elt.(*ast.BasicLit)
+		if !ok || bl.Kind != token.INT {
+			return nil, fmt.Errorf("item %d not an int", idx)
+		}
+		i, err := strconv.Atoi(bl.Value)
+		if err != nil {
+			return nil, fmt.Errorf("item %d: %v", idx, err)
+		}
+		list[idx] = i
+	}
+	return list, nil
+
+}
+
+// Stored returns the data entry idx as stored: compressed, but not encoded.
+func (g *Generated) Stored(idx int) ([]byte, error) {
+	return base64.StdEncoding.DecodeString(g.Data[idx])
+}
+
+// Decode returns the content of data entry idx.
+func (g *Generated) Decode(idx int) ([]byte, error) {
+
+	stored, err := g.Stored(idx)
+	if err != nil {
+		return nil, err
+	}
+	gzr, err := gzip.NewReader(bytes.NewReader(stored))
+	if err != nil {
+		return nil, err
+	}
+	defer gzr.Close()
+	return io.ReadAll(gzr)
+
+}
+
+// Asset returns the content of the named asset.
+func (g *Generated) Asset(name string) ([]byte, error) {
+
+	i := sort.SearchStrings(g.Names, name)
+	if i == len(g.Names) || g.Names[i] != name {
+		return nil, errors.New("Asset not found.")
+	}
+	return g.Decode(g.Index[i])
+
+}
+
+// Sums returns the sha256 sums of the content of all assets, by name.
+func (g *Generated) Sums() (map[string]string, error) {
+
+	data_sums := make([]string, len(g.Data))
+	sums := map[string]string{}
+	for idx, name := range g.Names {
+		didx := g.Index[idx]
+		if data_sums[didx] == "" {
+			b, err := g.Decode(didx)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", name, err)
+			}
+			data_sums[didx] = fmt.Sprintf("%x", sha256.Sum256(b))
+		}
+		sums[name] = data_sums[didx]
+	}
+	return sums, nil
+
+}
@@ -0,0 +1,196 @@
+// binsread_test.go - tests for stuff in binsread.go
+package binsanity_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/biztos/binsanity"
+)
+
+var LegacyDir = filepath.Join("testdata", "legacy")
+
+func TestReadGeneratedOk(t *testing.T) {
+
+	assert := assert.New(t)
+
+	gen, err := binsanity.ReadGenerated(filepath.Join(ExampleDir, "binsanity.go"))
+	if !assert.Nil(err, "no error") {
+		return
+	}
+	assert.Equal("main", gen.Package)
+	assert.Equal([]string{"bar", "baz/bat/bloopf", "baz/foo", "foo"}, gen.Names)
+	assert.Equal([]int{0, 1, 2, 2}, gen.Index)
+	assert.Equal(3, len(gen.Data))
+
+	for _, name := range gen.Names {
+		exp, err := os.ReadFile(filepath.Join(ExampleAssetDir, filepath.FromSlash(name)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := gen.Asset(name)
+		if assert.Nil(err, name) {
+			assert.Equal(string(exp), string(b), name)
+		}
+	}
+	_, err = gen.Asset("nope")
+	assert.EqualError(err, "Asset not found.")
+
+}
+
+func TestReadGeneratedOkLegacy(t *testing.T) {
+
+	assert := assert.New(t)
+
+	gen, err := binsanity.ReadGenerated(filepath.Join(LegacyDir, "binsanity.go"))
+	if !assert.Nil(err, "no error") {
+		return
+	}
+	assert.Equal([]string{"bar", "baz/bat/bloopf", "foo"}, gen.Names)
+	assert.Equal([]int{0, 1, 2}, gen.Index)
+	b, err := gen.Asset("baz/bat/bloopf")
+	if assert.Nil(err, "asset") {
+		assert.Equal("baz is bat is bloopf\n\n", string(b))
+	}
+
+}
+
+func TestReadGeneratedOkSplitAndEmpty(t *testing.T) {
+
+	assert := assert.New(t)
+
+	tdir := t.TempDir()
+	adir := filepath.Join(tdir, "assets")
+	WriteTree(t, adir, manyFiles())
+	for _, split := range []int{0, 300} {
+		cfg := &binsanity.Config{
+			Dir:       adir,
+			File:      filepath.Join(tdir, "binsanity.go"),
+			Package:   "foo",
+			Module:    "example.com/foo",
+			SplitSize: split,
+		}
+		_, err := binsanity.Process(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		gen, err := binsanity.ReadGenerated(cfg.File)
+		if !assert.Nil(err, "no error reading split %d", split) {
+			return
+		}
+		sums, err := gen.Sums()
+		if assert.Nil(err, "sums") {
+			assert.Equal(50, len(sums), "all there")
+		}
+	}
+
+	// The placeholder for no assets is not an asset.
+	edir := filepath.Join(tdir, "empty")
+	if err := os.Mkdir(edir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &binsanity.Config{
+		Dir:     edir,
+		File:    filepath.Join(tdir, "empty.go"),
+		Package: "foo",
+		Module:  "example.com/foo",
+	}
+	if _, err := binsanity.Process(cfg); err != nil {
+		t.Fatal(err)
+	}
+	gen, err := binsanity.ReadGenerated(cfg.File)
+	if assert.Nil(err, "no error reading empty") {
+		assert.Empty(gen.Names)
+		assert.Equal(1, len(gen.Data))
+	}
+
+}
+
+func TestReadGeneratedErrors(t *testing.T) {
+
+	tdir := t.TempDir()
+
+	// All data decodes to "foo", gzipped.
+	const foo = `"H4sIAAAAAAAA/0rLzwcEAAD//yFlc4wDAAAA"`
+	tests := map[string]string{
+		"syntax": "package x\nvar = \n",
+		"no names": `package x
+var binsanity_data = []string{` + foo + `}`,
+		"names not strings": `package x
+var binsanity_names = []string{1}`,
+		"names bad string": `package x
+var binsanity_names = []string{"\z"}`,
+		"index not ints": `package x
+var binsanity_names = []string{"a"}
+var binsanity_index = []int{"a"}`,
+		"index not list": `package x
+var binsanity_names = []string{"a"}
+var binsanity_index = 0`,
+		"index bad int": `package x
+var binsanity_names = []string{"a"}
+var binsanity_index = []int{0x}`,
+		"index short": `package x
+var binsanity_names = []string{"a", "b"}
+var binsanity_index = []int{0}
+var binsanity_data = []string{` + foo + `}`,
+		"index out of range": `package x
+var binsanity_names = []string{"a", "b"}
+var binsanity_index = []int{0, 1}
+var binsanity_data = []string{` + foo + `}`,
+		"no data": `package x
+var binsanity_names = []string{"a"}`,
+		"join not ident": `package x
+var binsanity_names = []string{"a"}
+var binsanity_data = binsanity_join([]string{})`,
+		"join missing": `package x
+var binsanity_names = []string{"a"}
+var binsanity_data = binsanity_join(binsanity_data_001)`,
+	}
+	for name, src := range tests {
+		t.Run(name, func(t *testing.T) {
+			file := filepath.Join(tdir, "x.go")
+			WriteTree(t, tdir, map[string]string{"x.go": src})
+			_, err := binsanity.ReadGenerated(file)
+			assert.NotNil(t, err)
+		})
+	}
+
+	// Companion file problems.
+	sdir := filepath.Join(tdir, "split")
+	WriteTree(t, sdir, map[string]string{
+		"x.go": `package x
+var binsanity_names = []string{"a"}
+var binsanity_data = binsanity_join(binsanity_data_001)`,
+		"x_data_001.go": "package x\nvar = \n",
+	})
+	_, err := binsanity.ReadGenerated(filepath.Join(sdir, "x.go"))
+	assert.NotNil(t, err, "bad companion")
+	_, err = binsanity.ReadGenerated(filepath.Join(NonesuchDir, "x.go"))
+	assert.NotNil(t, err, "no file")
+
+}
+
+func TestGeneratedDecodeErrors(t *testing.T) {
+
+	assert := assert.New(t)
+
+	gen := &binsanity.Generated{
+		Names: []string{"bad64", "notgzip", "truncated"},
+		Index: []int{0, 1, 2},
+		Data: []string{
+			"!!!",
+			"Zm9vCg==",
+			"H4sIAAAAAAAA/0rLzwcE",
+		},
+	}
+	for _, name := range gen.Names {
+		_, err := gen.Asset(name)
+		assert.NotNil(err, name)
+	}
+	_, err := gen.Sums()
+	assert.ErrorContains(err, "bad64: ")
+
+}
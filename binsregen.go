@@ -0,0 +1,127 @@
+// binsregen.go -- binsanity config recorded in generated files.
+
+package binsanity
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ConfigDirective starts the line in a generated code file that records the
+// configuration it was generated with, as JSON.
+const ConfigDirective = "//binsanity:config "
+
+// ErrNoConfig is the error for a code file without a recorded configuration,
+// such as one generated by an older version of binsanity.
+var ErrNoConfig = errors.New("No binsanity config found.")
+
+// RecordedConfig is the configuration recorded in a generated code file:
+// everything that affects the output, and the binsanity version used.
+//
+// Dir is relative to the directory of the code file, in slash format, unless
+// that is impossible.
+type RecordedConfig struct {
+	Version   string   `json:"version"`
+	Dir       string   `json:"dir"`
+	Package   string   `json:"package"`
+	Module    string   `json:"module"`
+	SplitSize int      `json:"split_size,omitempty"`
+	Include   []string `json:"include,omitempty"`
+	Exclude   []string `json:"exclude,omitempty"`
+	Compat    bool     `json:"compat,omitempty"`
+	Meta      bool     `json:"meta,omitempty"`
+	FS        bool     `json:"fs,omitempty"`
+}
+
+// recordConfig returns the config to be recorded in the code file for
+// generating it with cfg, given the effective file, package and module.
+func recordConfig(cfg *Config, file, pkg, mod string) *RecordedConfig {
+
+	rec := &RecordedConfig{
+		Version:   Version,
+		Dir:       filepath.ToSlash(cfg.Dir),
+		Package:   pkg,
+		Module:    mod,
+		SplitSize: cfg.SplitSize,
+		Include:   cfg.Include,
+		Exclude:   cfg.Exclude,
+		Compat:    cfg.Compat,
+		Meta:      cfg.Meta,
+		FS:        cfg.FS,
+	}
+	absdir, err := filepath.Abs(cfg.Dir)
+	if err != nil {
+		return rec
+	}
+	absfile, err := filepath.Abs(file)
+	if err != nil {
+		return rec
+	}
+	if rel, err := filepath.Rel(filepath.Dir(absfile), absdir); err == nil {
+		rec.Dir = filepath.ToSlash(rel)
+	}
+	return rec
+
+}
+
+// String returns the JSON form of the config, as recorded.
+func (rc *RecordedConfig) String() string {
+	b, _ := json.Marshal(rc) // can't fail for plain strings and ints
+	return string(b)
+}
+
+// ReadConfig returns the configuration recorded in the code file at file,
+// which is found before its package clause.  ErrNoConfig is returned if
+// there is none.
+func ReadConfig(file string) (*RecordedConfig, error) {
+
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "package ") {
+			break
+		}
+		if strings.HasPrefix(line, ConfigDirective) {
+			rec := &RecordedConfig{}
+			js := strings.TrimPrefix(line, ConfigDirective)
+			if err := json.Unmarshal([]byte(js), rec); err != nil {
+				return nil, fmt.Errorf("%s: config: %v", file, err)
+			}
+			return rec, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("%s: %w", file, ErrNoConfig)
+
+}
+
+// Config returns a Config for regenerating the code file at file as it was
+// generated, with a relative Dir taken as relative to the file's directory.
+func (rc *RecordedConfig) Config(file string) *Config {
+
+	return &Config{
+		Dir:       resolvePath(filepath.Dir(file), rc.Dir),
+		Package:   rc.Package,
+		Module:    rc.Module,
+		File:      file,
+		SplitSize: rc.SplitSize,
+		Include:   rc.Include,
+		Exclude:   rc.Exclude,
+		Compat:    rc.Compat,
+		Meta:      rc.Meta,
+		FS:        rc.FS,
+	}
+
+}
@@ -0,0 +1,182 @@
+// binsregen_test.go - tests for stuff in binsregen.go
+package binsanity_test
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/biztos/binsanity"
+)
+
+func TestReadConfig(t *testing.T) {
+
+	assert := assert.New(t)
+
+	file := filepath.Join(ExampleDir, "binsanity.go")
+	rec, err := binsanity.ReadConfig(file)
+	if !assert.Nil(err, "no error") {
+		return
+	}
+	assert.Equal(&binsanity.RecordedConfig{
+		Version: binsanity.Version,
+		Dir:     "assets",
+		Package: "main",
+		Module:  "biztos.com/example",
+	}, rec, "recorded")
+	assert.Equal(&binsanity.Config{
+		Dir:     ExampleAssetDir,
+		Package: "main",
+		Module:  "biztos.com/example",
+		File:    file,
+	}, rec.Config(file), "config")
+
+	// An absolute dir stays put.
+	abs, _ := filepath.Abs(ExampleAssetDir)
+	rec.Dir = filepath.ToSlash(abs)
+	assert.Equal(abs, rec.Config(file).Dir, "absolute")
+
+}
+
+func TestReadConfigErrors(t *testing.T) {
+
+	assert := assert.New(t)
+
+	tdir := t.TempDir()
+	WriteTree(t, tdir, map[string]string{
+		"bad.go":  binsanity.ConfigDirective + "{nope\npackage foo\n",
+		"late.go": "package foo\n\n" + binsanity.ConfigDirective + "{}\n",
+	})
+
+	_, err := binsanity.ReadConfig(filepath.Join(LegacyDir, "binsanity.go"))
+	assert.ErrorIs(err, binsanity.ErrNoConfig, "legacy")
+	_, err = binsanity.ReadConfig(filepath.Join(tdir, "late.go"))
+	assert.ErrorIs(err, binsanity.ErrNoConfig, "after package")
+	_, err = binsanity.ReadConfig(filepath.Join(tdir, "bad.go"))
+	assert.ErrorContains(err, "bad.go: config: ", "bad json")
+	_, err = binsanity.ReadConfig(filepath.Join(tdir, "nope.go"))
+	assert.Error(err, "missing")
+	_, err = binsanity.ReadConfig(tdir)
+	assert.Error(err, "dir")
+
+}
+
+func TestProcessRecordsConfig(t *testing.T) {
+
+	assert := assert.New(t)
+
+	// Dir is recorded relative to the code file, wherever we run from.
+	tdir := t.TempDir()
+	WriteTree(t, tdir, map[string]string{"x/assets/a": "aaa"})
+	cfg := &binsanity.Config{
+		Dir:       filepath.Join(tdir, "x", "assets"),
+		File:      filepath.Join(tdir, "y", "z", "gen.go"),
+		Package:   "foo",
+		Module:    "example.com/foo",
+		SplitSize: 1000,
+		Jobs:      3,
+		Exclude:   []string{"*.bak"},
+		Compat:    true,
+		Meta:      true,
+		FS:        true,
+	}
+	WriteTree(t, tdir, map[string]string{"y/z/keep": ""})
+	if _, err := binsanity.Process(cfg); !assert.Nil(err, "no error") {
+		return
+	}
+	rec, err := binsanity.ReadConfig(cfg.File)
+	if !assert.Nil(err, "read") {
+		return
+	}
+	assert.Equal(&binsanity.RecordedConfig{
+		Version:   binsanity.Version,
+		Dir:       "../../x/assets",
+		Package:   "foo",
+		Module:    "example.com/foo",
+		SplitSize: 1000,
+		Exclude:   []string{"*.bak"},
+		Compat:    true,
+		Meta:      true,
+		FS:        true,
+	}, rec, "recorded")
+	assert.Equal(cfg.Dir, rec.Config(cfg.File).Dir, "resolved")
+	assert.True(rec.Config(cfg.File).Compat, "compat")
+	assert.True(rec.Config(cfg.File).Meta, "meta")
+	assert.True(rec.Config(cfg.File).FS, "fs")
+
+}
+
+func TestRunAppRegen(t *testing.T) {
+
+	assert := assert.New(t)
+
+	tdir := t.TempDir()
+	CopyTree(t, ExampleDir, tdir)
+	file := filepath.Join(tdir, "binsanity.go")
+	WriteTree(t, tdir, map[string]string{"assets/new": "new!"})
+
+	exit_code := 0
+	binsanity.ExitFunc = func(c int) { exit_code = c }
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	binsanity.OutWriter = stdout
+	binsanity.ErrWriter = stderr
+	defer RestoreDefaults()
+
+	// Out of date now.
+	binsanity.RunApp([]string{"appname", "regen", "--check", file})
+	assert.Equal(1, exit_code, "exit 1")
+	assert.Contains(stdout.String(), "+ new\n", "new asset")
+
+	// Regenerate it.
+	exit_code = 0
+	stdout.Reset()
+	stderr.Reset()
+	binsanity.RunApp([]string{"appname", "regen", file})
+	assert.Equal(0, exit_code, "exit 0")
+	assert.Equal("files: 5, bytes: 62, saved: 52\n", stdout.String(), "stdout")
+	assert.Equal("", stderr.String(), "stderr")
+	rec, err := binsanity.ReadConfig(file)
+	if assert.Nil(err, "read") {
+		assert.Equal("main", rec.Package, "package kept")
+		assert.Equal("biztos.com/example", rec.Module, "module kept")
+	}
+
+	// A different version is noted.
+	b := readAll(t, tdir)["binsanity.go"]
+	b = strings.Replace(b, `"version":"`+binsanity.Version, `"version":"v0.0.1`, 1)
+	WriteTree(t, tdir, map[string]string{"binsanity.go": b})
+	stderr.Reset()
+	binsanity.RunApp([]string{"appname", "regen", "--dry-run", file})
+	assert.Equal(0, exit_code, "exit 0")
+	assert.Equal("Generated by binsanity v0.0.1, regenerating with "+
+		binsanity.Version+".\n", stderr.String(), "stderr")
+
+}
+
+func TestRunAppRegenErrors(t *testing.T) {
+
+	assert := assert.New(t)
+
+	exit_code := 0
+	binsanity.ExitFunc = func(c int) { exit_code = c }
+	stderr := &bytes.Buffer{}
+	binsanity.OutWriter = &bytes.Buffer{}
+	binsanity.ErrWriter = stderr
+	defer RestoreDefaults()
+
+	binsanity.RunApp([]string{"appname", "regen"})
+	assert.Equal(1, exit_code, "exit 1")
+	assert.Equal("Single arg required: FILE.go\n", stderr.String(), "no arg")
+
+	exit_code = 0
+	stderr.Reset()
+	binsanity.RunApp([]string{"appname", "regen", filepath.Join(LegacyDir, "binsanity.go")})
+	assert.Equal(1, exit_code, "exit 1")
+	assert.Equal(filepath.Join(LegacyDir, "binsanity.go")+": No binsanity config found.\n",
+		stderr.String(), "legacy")
+
+}
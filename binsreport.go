@@ -0,0 +1,58 @@
+// binsreport.go -- binsanity per-asset reporting.
+
+package binsanity
+
+import (
+	"bytes"
+	"fmt"
+	"text/tabwriter"
+)
+
+// AssetResult describes a single asset processed: its name and source path,
+// its original size, the size of its content once compressed with Codec, and
+// its sha256 sum.
+//
+// Assets with identical content share their stored data, so they have the
+// same Stored size, but it is only counted once in the generated source.
+type AssetResult struct {
+	Name   string `json:"name"`
+	Path   string `json:"path"`
+	Size   int    `json:"size"`
+	Stored int    `json:"stored"`
+	Codec  string `json:"codec"`
+	Sum    string `json:"sum"`
+}
+
+// Ratio returns the compressed size as a fraction of the original size, or
+// zero for an empty asset.
+func (a *AssetResult) Ratio() float64 {
+	if a.Size == 0 {
+		return 0
+	}
+	return float64(a.Stored) / float64(a.Size)
+}
+
+// Table returns a table of the assets in r, as for AssetTable.
+func (r *Result) Table() string {
+	return AssetTable(r.Assets)
+}
+
+// AssetTable returns a table of the assets, one per line under a header
+// line, with the ratio given as a percentage.  The path is left out.
+func AssetTable(assets []*AssetResult) string {
+
+	buf := &bytes.Buffer{}
+	tw := tabwriter.NewWriter(buf, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tSIZE\tSTORED\tRATIO\tCODEC\tSHA256")
+	for _, a := range assets {
+		ratio := "-"
+		if a.Size > 0 {
+			ratio = fmt.Sprintf("%.1f%%", 100*a.Ratio())
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%s\t%s\t%s\n",
+			a.Name, a.Size, a.Stored, ratio, a.Codec, a.Sum)
+	}
+	tw.Flush()
+	return buf.String()
+
+}
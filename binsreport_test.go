@@ -0,0 +1,38 @@
+// binsreport_test.go - tests for stuff in binsreport.go
+package binsanity_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/biztos/binsanity"
+)
+
+func TestAssetResultRatio(t *testing.T) {
+
+	assert := assert.New(t)
+
+	a := &binsanity.AssetResult{Size: 200, Stored: 50}
+	assert.Equal(0.25, a.Ratio(), "ratio")
+	a = &binsanity.AssetResult{Size: 0, Stored: 23}
+	assert.Equal(0.0, a.Ratio(), "empty")
+
+}
+
+func TestResultTable(t *testing.T) {
+
+	assert := assert.New(t)
+
+	res := &binsanity.Result{
+		Assets: []*binsanity.AssetResult{
+			{Name: "a", Size: 200, Stored: 50, Codec: "gzip", Sum: "abc"},
+			{Name: "bb/cc", Size: 0, Stored: 23, Codec: "gzip", Sum: "def"},
+		},
+	}
+	assert.Equal(`NAME   SIZE  STORED  RATIO  CODEC  SHA256
+a      200   50      25.0%  gzip   abc
+bb/cc  0     23      -      gzip   def
+`, res.Table())
+
+}
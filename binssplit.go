@@ -0,0 +1,154 @@
+// binssplit.go -- binsanity companion files for split asset data.
+
+package binsanity
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+// DataFileName returns the name of the nth (from 1) companion data file for
+// the code file: "binsanity_data_001.go" for "binsanity.go" and so on.
+func DataFileName(file string, n int) string {
+	return fmt.Sprintf("%s_data_%03d.go", strings.TrimSuffix(file, ".go"), n)
+}
+
+// dataVarName returns the name of the data table in the nth companion file.
+func dataVarName(n int) string {
+	return fmt.Sprintf("binsanity_data_%03d", n)
+}
+
+// DataFiles returns the paths of any existing companion data files for the
+// code file, in no particular order.
+func DataFiles(file string) ([]string, error) {
+
+	base := strings.TrimSuffix(filepath.Base(file), ".go")
+	re := regexp.MustCompile("^" + regexp.QuoteMeta(base) + `_data_\d{3,}\.go$`)
+	entries, err := os.ReadDir(filepath.Dir(file))
+	if err != nil {
+		return nil, err
+	}
+	paths := []string{}
+	for _, entry := range entries {
+		if re.MatchString(entry.Name()) {
+			paths = append(paths, filepath.Join(filepath.Dir(file), entry.Name()))
+		}
+	}
+	return paths, nil
+
+}
+
+// chunkData splits the stream of encoded data into a stream of streams,
+// each holding no more than size bytes of data unless a single entry is
+// itself bigger.  Closing quit abandons the rest.
+func chunkData(data <-chan string, size int, quit <-chan struct{}) <-chan (<-chan string) {
+
+	chunks := make(chan (<-chan string))
+	go func() {
+		defer close(chunks)
+		var cur chan string
+		n := 0
+		for s := range data {
+			// Each entry is written as: TAB QUOTE data QUOTE COMMA NEWLINE
+			line := len(s) + 5
+			if cur != nil && n+line > size {
+				close(cur)
+				cur = nil
+			}
+			if cur == nil {
+				cur = make(chan string)
+				n = 0
+				select {
+				case chunks <- cur:
+				case <-quit:
+					return
+				}
+			}
+			select {
+			case cur <- s:
+			case <-quit:
+				return
+			}
+			n += line
+		}
+		if cur != nil {
+			close(cur)
+		}
+	}()
+	return chunks
+
+}
+
+// writeDataFiles renders the data in gen.DataStrings into companion files
+// in out for the code file, of about size bytes each, and sets gen.DataVars
+// to the names of their data tables.  The paths of the files are returned.
+func writeDataFiles(out *fileSet, file string, gen *GenData, size int) ([]string, error) {
+
+	dtmpl, err := template.New("t").Parse(DataTemplate)
+	if err != nil {
+		return nil, &ProcessError{StageRender, "data.tmpl", err}
+	}
+
+	quit := make(chan struct{})
+	defer close(quit)
+	paths := []string{}
+	gen.DataVars = []string{}
+	for chunk := range chunkData(gen.DataStrings, size, quit) {
+		n := len(paths) + 1
+		path := DataFileName(file, n)
+		dgen := *gen
+		dgen.CodeFile = filepath.Base(path)
+		dgen.MainFile = gen.CodeFile
+		dgen.DataVar = dataVarName(n)
+		dgen.DataStrings = chunk
+		if err := out.Render(path, dtmpl, &dgen); err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+		gen.DataVars = append(gen.DataVars, dgen.DataVar)
+	}
+	return paths, nil
+
+}
+
+// staleDataFiles returns any companion data files for the code file other
+// than those in keep, as left behind by earlier runs.
+func staleDataFiles(file string, keep []string) ([]string, error) {
+
+	paths, err := DataFiles(file)
+	if err != nil {
+		return nil, err
+	}
+	wanted := map[string]bool{}
+	for _, path := range keep {
+		wanted[filepath.Base(path)] = true
+	}
+	stale := []string{}
+	for _, path := range paths {
+		if !wanted[filepath.Base(path)] {
+			stale = append(stale, path)
+		}
+	}
+	return stale, nil
+
+}
+
+// removeStaleDataFiles removes any stale companion data files.
+func removeStaleDataFiles(file string, keep []string) error {
+
+	paths, err := staleDataFiles(file, keep)
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+	}
+	return nil
+
+}
@@ -0,0 +1,116 @@
+// binssplit_test.go - tests for stuff in binssplit.go
+package binsanity_test
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/biztos/binsanity"
+)
+
+func TestDataFileName(t *testing.T) {
+
+	assert := assert.New(t)
+
+	assert.Equal("binsanity_data_001.go", binsanity.DataFileName("binsanity.go", 1))
+	assert.Equal(filepath.Join("foo", "bar_data_1234.go"),
+		binsanity.DataFileName(filepath.Join("foo", "bar.go"), 1234))
+
+}
+
+func TestDataFilesErr(t *testing.T) {
+
+	assert := assert.New(t)
+
+	_, err := binsanity.DataFiles(filepath.Join(NonesuchDir, "foo.go"))
+	assert.True(os.IsNotExist(err))
+
+}
+
+func TestProcessSplit(t *testing.T) {
+
+	assert := assert.New(t)
+
+	tdir := t.TempDir()
+	adir := filepath.Join(tdir, "assets")
+	WriteTree(t, adir, manyFiles())
+	file := filepath.Join(tdir, "binsanity.go")
+
+	// Unrelated files that just look a bit like companions stay put.
+	WriteTree(t, tdir, map[string]string{
+		"binsanity_data_1.go":   "package foo",
+		"binsanity_data_x01.go": "package foo",
+		"other_data_001.go":     "package foo",
+	})
+
+	// Returns the sorted companion file names and checks their size.
+	process := func(size int) []string {
+		cfg := &binsanity.Config{
+			Dir:       adir,
+			File:      file,
+			Package:   "foo",
+			Module:    "example.com/foo",
+			SplitSize: size,
+		}
+		_, err := binsanity.Process(cfg)
+		if !assert.Nil(err, "no error for split size %d", size) {
+			t.FailNow()
+		}
+		paths, err := binsanity.DataFiles(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		names := []string{}
+		for _, path := range paths {
+			names = append(names, filepath.Base(path))
+			b, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			lines := strings.Split(string(b), "\n")
+			data := 0
+			entries := 0
+			for _, line := range lines {
+				if strings.HasPrefix(line, "\t\"") {
+					data += len(line) + 1
+					entries++
+				}
+			}
+			assert.True(data <= size || entries == 1,
+				"%s: %d bytes of data over %d", path, data, size)
+		}
+		sort.Strings(names)
+		return names
+	}
+
+	small := process(400)
+	assert.True(len(small) > 3, "several files")
+	assert.Equal("binsanity_data_001.go", small[0])
+	b, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(string(b), "var binsanity_data = binsanity_join(\n\tbinsanity_data_001,\n")
+	assert.NotContains(string(b), "H4sI", "no data in code file")
+
+	// Fewer files next time, and the extras are gone.
+	big := process(4000)
+	assert.True(len(big) < len(small), "fewer files")
+	assert.Equal(small[:len(big)], big)
+
+	// None when not splitting.
+	assert.Equal([]string{}, process(0))
+	for _, name := range []string{
+		"binsanity_data_1.go",
+		"binsanity_data_x01.go",
+		"other_data_001.go",
+	} {
+		assert.FileExists(filepath.Join(tdir, name))
+	}
+
+}
@@ -0,0 +1,101 @@
+// binsverify.go -- binsanity verification of generated files.
+
+package binsanity
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// CorruptAsset is an asset that failed verification, and why.
+type CorruptAsset struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// Verification is returned by Verify and records the number of assets
+// checked and those found to be corrupt, sorted by name.
+type Verification struct {
+	File     string          `json:"file"`
+	TestFile string          `json:"test_file"`
+	Assets   int             `json:"assets"`
+	Corrupt  []*CorruptAsset `json:"corrupt"`
+}
+
+// OK returns true if no assets are corrupt.
+func (v *Verification) OK() bool {
+	return len(v.Corrupt) == 0
+}
+
+// String returns the pretty-print version of Verification: a summary line,
+// followed by a line for each corrupt asset.
+func (v *Verification) String() string {
+
+	lines := []string{
+		fmt.Sprintf("assets: %d, corrupt: %d", v.Assets, len(v.Corrupt)),
+	}
+	for _, c := range v.Corrupt {
+		lines = append(lines, fmt.Sprintf("! %s: %s", c.Name, c.Reason))
+	}
+	return strings.Join(lines, "\n")
+
+}
+
+// Verify decodes every asset in the code file generated by binsanity at file
+// and compares its content with the sum recorded in the test file generated
+// with it, without running any tests.  Assets that can't be decoded, don't
+// match or have no sum are corrupt, as are sums for assets that are missing.
+//
+// Corrupt assets are not an error: it is up to the caller to check OK.  Not
+// being able to read either file is.
+func Verify(file string) (*Verification, error) {
+
+	gen, err := ReadGenerated(file)
+	if err != nil {
+		return nil, err
+	}
+	tfile := TestFileName(file)
+	sums, err := ReadTestSums(tfile)
+	if err != nil {
+		return nil, err
+	}
+
+	v := &Verification{
+		File:     file,
+		TestFile: tfile,
+		Assets:   len(gen.Names),
+		Corrupt:  []*CorruptAsset{},
+	}
+	corrupt := func(name, reason string) {
+		v.Corrupt = append(v.Corrupt, &CorruptAsset{Name: name, Reason: reason})
+	}
+	found := map[string]bool{}
+	for idx, name := range gen.Names {
+		found[name] = true
+		want, ok := sums[name]
+		if !ok {
+			corrupt(name, "no sum")
+			continue
+		}
+		b, err := gen.Decode(gen.Index[idx])
+		if err != nil {
+			corrupt(name, err.Error())
+			continue
+		}
+		if sum := fmt.Sprintf("%x", sha256.Sum256(b)); sum != want {
+			corrupt(name, "sum mismatch")
+		}
+	}
+	for name := range sums {
+		if !found[name] {
+			corrupt(name, "missing")
+		}
+	}
+	sort.Slice(v.Corrupt, func(i, j int) bool {
+		return v.Corrupt[i].Name < v.Corrupt[j].Name
+	})
+	return v, nil
+
+}
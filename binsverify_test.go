@@ -0,0 +1,162 @@
+// binsverify_test.go - tests for stuff in binsverify.go
+package binsanity_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/biztos/binsanity"
+)
+
+func TestVerify(t *testing.T) {
+
+	assert := assert.New(t)
+
+	file := filepath.Join(ExampleDir, "binsanity.go")
+	v, err := binsanity.Verify(file)
+	if !assert.Nil(err, "no error") {
+		return
+	}
+	assert.True(v.OK(), "ok")
+	assert.Equal(filepath.Join(ExampleDir, "binsanity_test.go"), v.TestFile, "test file")
+	assert.Equal(4, v.Assets, "assets")
+	assert.Equal("assets: 4, corrupt: 0", v.String(), "string")
+
+}
+
+func TestVerifyEmpty(t *testing.T) {
+
+	assert := assert.New(t)
+
+	tdir := t.TempDir()
+	file := filepath.Join(tdir, "binsanity.go")
+	_, err := binsanity.Process(&binsanity.Config{
+		Dir:     tdir,
+		File:    file,
+		Package: "main",
+		Module:  "x",
+	})
+	if !assert.Nil(err, "no error") {
+		return
+	}
+	v, err := binsanity.Verify(file)
+	if assert.Nil(err, "no error") {
+		assert.True(v.OK(), "ok")
+		assert.Equal(0, v.Assets, "assets")
+	}
+
+}
+
+func TestVerifyCorrupt(t *testing.T) {
+
+	assert := assert.New(t)
+
+	tdir := t.TempDir()
+	CopyTree(t, ExampleDir, tdir)
+	file := filepath.Join(tdir, "binsanity.go")
+	files := readAll(t, tdir)
+	src := files["binsanity.go"]
+	tsrc := files["binsanity_test.go"]
+	WriteTree(t, tdir, map[string]string{
+		"binsanity.go": strings.Replace(src, `"H4sI`, `"!!!!`, 1),
+		"binsanity_test.go": strings.Replace(
+			strings.Replace(tsrc, `"foo",`, `"fooz",`, 1),
+			"4fed59a4", "00000000", 1),
+	})
+
+	v, err := binsanity.Verify(file)
+	if !assert.Nil(err, "no error") {
+		return
+	}
+	assert.False(v.OK(), "not ok")
+	assert.Equal(4, v.Assets, "assets")
+	if assert.Equal(4, len(v.Corrupt), "corrupt") {
+		assert.Equal("bar", v.Corrupt[0].Name, "name")
+		assert.Contains(v.Corrupt[0].Reason, "illegal base64", "reason")
+		assert.Equal(&binsanity.CorruptAsset{Name: "baz/bat/bloopf", Reason: "sum mismatch"},
+			v.Corrupt[1], "mismatch")
+		assert.Equal(&binsanity.CorruptAsset{Name: "foo", Reason: "no sum"},
+			v.Corrupt[2], "no sum")
+		assert.Equal(&binsanity.CorruptAsset{Name: "fooz", Reason: "missing"},
+			v.Corrupt[3], "missing")
+	}
+	lines := strings.Split(v.String(), "\n")
+	assert.Equal("assets: 4, corrupt: 4", lines[0], "summary")
+	assert.Equal("! foo: no sum", lines[3], "line")
+
+}
+
+func TestVerifyErrors(t *testing.T) {
+
+	assert := assert.New(t)
+
+	_, err := binsanity.Verify(filepath.Join(ExampleDir, "nope.go"))
+	assert.Error(err, "missing file")
+
+	tdir := t.TempDir()
+	CopyTree(t, ExampleDir, tdir)
+	if err := os.Remove(filepath.Join(tdir, "binsanity_test.go")); err != nil {
+		t.Fatal(err)
+	}
+	_, err = binsanity.Verify(filepath.Join(tdir, "binsanity.go"))
+	assert.Error(err, "missing test file")
+
+}
+
+func TestRunAppVerify(t *testing.T) {
+
+	assert := assert.New(t)
+
+	exit_code := 0
+	binsanity.ExitFunc = func(c int) { exit_code = c }
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	binsanity.OutWriter = stdout
+	binsanity.ErrWriter = stderr
+	defer RestoreDefaults()
+
+	binsanity.RunApp([]string{"appname", "verify", filepath.Join(ExampleDir, "binsanity.go")})
+	assert.Equal(0, exit_code, "exit 0")
+	assert.Equal("assets: 4, corrupt: 0\n", stdout.String(), "stdout")
+
+	tdir := t.TempDir()
+	CopyTree(t, ExampleDir, tdir)
+	file := filepath.Join(tdir, "binsanity.go")
+	src := readAll(t, tdir)["binsanity.go"]
+	WriteTree(t, tdir, map[string]string{
+		"binsanity.go": strings.Replace(src, `"H4sI`, `"AAAA`, 1),
+	})
+
+	stdout.Reset()
+	binsanity.RunApp([]string{"appname", "verify", file})
+	assert.Equal(1, exit_code, "exit 1")
+	assert.True(strings.HasPrefix(stdout.String(), "assets: 4, corrupt: 1\n! bar: "), "stdout")
+	assert.Equal(file+": 1 corrupt assets.\n", stderr.String(), "stderr")
+
+	exit_code = 0
+	stdout.Reset()
+	binsanity.RunApp([]string{"appname", "verify", "--json", file})
+	assert.Equal(1, exit_code, "exit 1")
+	v := &binsanity.Verification{}
+	if assert.Nil(json.Unmarshal(stdout.Bytes(), v), "json") {
+		assert.Equal(4, v.Assets, "assets")
+		assert.Equal(1, len(v.Corrupt), "corrupt")
+	}
+
+	exit_code = 0
+	stderr.Reset()
+	binsanity.RunApp([]string{"appname", "verify"})
+	assert.Equal(1, exit_code, "exit 1")
+	assert.Equal("Single arg required: FILE.go\n", stderr.String(), "stderr")
+
+	exit_code = 0
+	binsanity.RunApp([]string{"appname", "verify", filepath.Join(tdir, "nope.go")})
+	assert.Equal(1, exit_code, "exit 1")
+
+}
@@ -0,0 +1,121 @@
+// binswatch.go -- binsanity regeneration on changes to the assets.
+
+package binsanity
+
+import (
+	"os"
+	"os/signal"
+	"path/filepath"
+	"time"
+)
+
+// WatchInterval is how often Watch polls the asset tree for changes.
+var WatchInterval = 500 * time.Millisecond
+
+// WatchDebounce is how long the asset tree must be left alone after a change
+// before Watch regenerates, so a burst of changes only regenerates once.
+var WatchDebounce = 300 * time.Millisecond
+
+// NotifySignal is used to catch interrupts when watching, override for
+// testing.
+var NotifySignal = signal.Notify
+
+// StopSignal is used to stop catching them.
+var StopSignal = signal.Stop
+
+// fileState is what is compared between polls for each file or directory.
+type fileState struct {
+	size  int64
+	mode  os.FileMode
+	mtime time.Time
+}
+
+// snapshotTree returns the state of everything under dir, by path.  Anything
+// that can't be looked at, including things deleted or renamed during the
+// walk, is left out; so is dir itself if it doesn't exist.
+func snapshotTree(dir string) map[string]fileState {
+
+	tree := map[string]fileState{}
+	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info == nil {
+			return nil
+		}
+		tree[path] = fileState{
+			size:  info.Size(),
+			mode:  info.Mode(),
+			mtime: info.ModTime(),
+		}
+		return nil
+	})
+	return tree
+
+}
+
+// sameTree returns true if the snapshots a and b are the same.
+func sameTree(a, b map[string]fileState) bool {
+
+	if len(a) != len(b) {
+		return false
+	}
+	for path, sa := range a {
+		sb, found := b[path]
+		if !found || sa.size != sb.size || sa.mode != sb.mode || !sa.mtime.Equal(sb.mtime) {
+			return false
+		}
+	}
+	return true
+
+}
+
+// Watch calls Process with cfg, then polls cfg.Dir every WatchInterval and
+// calls it again whenever anything in it has changed and been left alone
+// for WatchDebounce.  Each result is passed to each, errors included, and
+// watching goes on regardless.  Watch returns once stop is closed.
+func Watch(cfg *Config, stop <-chan struct{}, each func(*Result, error)) {
+
+	// The snapshot comes first, so changes made while processing are seen.
+	last := snapshotTree(cfg.Dir)
+	each(Process(cfg))
+	var changed time.Time // zero unless a change is pending
+
+	ticker := time.NewTicker(WatchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			tree := snapshotTree(cfg.Dir)
+			if !sameTree(last, tree) {
+				last = tree
+				changed = now
+				continue
+			}
+			if !changed.IsZero() && now.Sub(changed) >= WatchDebounce {
+				changed = time.Time{}
+				each(Process(cfg))
+			}
+		}
+	}
+
+}
+
+// WatchUntilInterrupt calls Watch until an interrupt signal (SIGINT) is
+// received.
+func WatchUntilInterrupt(cfg *Config, each func(*Result, error)) {
+
+	sigs := make(chan os.Signal, 1)
+	NotifySignal(sigs, os.Interrupt)
+	defer StopSignal(sigs)
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		Watch(cfg, stop, each)
+	}()
+	<-sigs
+	close(stop)
+	<-done
+
+}
@@ -0,0 +1,257 @@
+// binswatch_test.go - tests for stuff in binswatch.go
+package binsanity_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/biztos/binsanity"
+)
+
+// syncBuffer is a bytes.Buffer safe to write from one goroutine while
+// reading from another.
+type syncBuffer struct {
+	mutex sync.Mutex
+	buf   bytes.Buffer
+}
+
+func (sb *syncBuffer) Write(p []byte) (int, error) {
+	sb.mutex.Lock()
+	defer sb.mutex.Unlock()
+	return sb.buf.Write(p)
+}
+
+func (sb *syncBuffer) String() string {
+	sb.mutex.Lock()
+	defer sb.mutex.Unlock()
+	return sb.buf.String()
+}
+
+// waitFor polls cond for up to a few seconds, failing if it never holds.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	for i := 0; i < 500; i++ {
+		if cond() {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("timed out waiting for " + what)
+}
+
+func TestWatch(t *testing.T) {
+
+	assert := assert.New(t)
+
+	binsanity.WatchInterval = 5 * time.Millisecond
+	binsanity.WatchDebounce = 50 * time.Millisecond
+	defer RestoreDefaults()
+
+	tdir := t.TempDir()
+	adir := filepath.Join(tdir, "assets")
+	WriteTree(t, adir, map[string]string{"a": "aaa", "b/c": "ccc"})
+	cfg := &binsanity.Config{
+		Dir:     adir,
+		File:    filepath.Join(tdir, "binsanity.go"),
+		Package: "foo",
+		Module:  "example.com/foo",
+	}
+
+	var mutex sync.Mutex
+	results := []string{}
+	each := func(res *binsanity.Result, err error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		if err != nil {
+			results = append(results, "error")
+		} else {
+			results = append(results, res.String())
+		}
+	}
+	count := func() int {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return len(results)
+	}
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		binsanity.Watch(cfg, stop, each)
+	}()
+	waitFor(t, "first run", func() bool { return count() == 1 })
+
+	// Nothing changes, nothing happens.
+	time.Sleep(60 * time.Millisecond)
+	assert.Equal(1, count(), "no change")
+
+	// A burst of changes is one run.
+	WriteTree(t, adir, map[string]string{"a": "aaaa"})
+	WriteTree(t, adir, map[string]string{"d": "dd"})
+	if err := os.RemoveAll(filepath.Join(adir, "b")); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "second run", func() bool { return count() == 2 })
+
+	// Errors are passed on, and watching goes on.
+	if err := os.RemoveAll(adir); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "third run", func() bool { return count() == 3 })
+	WriteTree(t, adir, map[string]string{"e": "e"})
+	waitFor(t, "fourth run", func() bool { return count() == 4 })
+
+	close(stop)
+	<-done
+	assert.Equal([]string{
+		"files: 2, bytes: 6",
+		"files: 2, bytes: 6",
+		"error",
+		"files: 1, bytes: 1",
+	}, results, "results")
+
+}
+
+func TestWatchChangeDuringFirstRun(t *testing.T) {
+
+	assert := assert.New(t)
+
+	binsanity.WatchInterval = 5 * time.Millisecond
+	binsanity.WatchDebounce = 20 * time.Millisecond
+	defer RestoreDefaults()
+
+	tdir := t.TempDir()
+	adir := filepath.Join(tdir, "assets")
+	WriteTree(t, adir, map[string]string{"a": "aaa"})
+	cfg := &binsanity.Config{
+		Dir:     adir,
+		File:    filepath.Join(tdir, "binsanity.go"),
+		Package: "foo",
+		Module:  "example.com/foo",
+	}
+
+	// A file added after the dir is scanned is missed by the first run, but
+	// not by the watching.
+	var once sync.Once
+	binsanity.OsOpen = func(name string) (*os.File, error) {
+		once.Do(func() { WriteTree(t, adir, map[string]string{"late": "l"}) })
+		return os.Open(name)
+	}
+
+	var mutex sync.Mutex
+	results := []string{}
+	each := func(res *binsanity.Result, err error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		if err != nil {
+			results = append(results, err.Error())
+		} else {
+			results = append(results, res.String())
+		}
+	}
+	count := func() int {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return len(results)
+	}
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		binsanity.Watch(cfg, stop, each)
+	}()
+	waitFor(t, "second run", func() bool { return count() == 2 })
+	close(stop)
+	<-done
+	assert.Equal([]string{"files: 1, bytes: 3", "files: 2, bytes: 4"}, results, "results")
+
+}
+
+func TestRunAppWatch(t *testing.T) {
+
+	assert := assert.New(t)
+
+	binsanity.WatchInterval = 5 * time.Millisecond
+	binsanity.WatchDebounce = 20 * time.Millisecond
+	sigs := make(chan chan<- os.Signal, 1)
+	binsanity.NotifySignal = func(c chan<- os.Signal, sig ...os.Signal) {
+		sigs <- c
+	}
+	stopped := false
+	binsanity.StopSignal = func(c chan<- os.Signal) { stopped = true }
+	defer RestoreDefaults()
+
+	tdir := t.TempDir()
+	adir := filepath.Join(tdir, "assets")
+	WriteTree(t, adir, map[string]string{"a": "aaa"})
+
+	exit_code := 0
+	binsanity.ExitFunc = func(c int) { exit_code = c }
+	stdout := &syncBuffer{}
+	stderr := &syncBuffer{}
+	binsanity.OutWriter = stdout
+	binsanity.ErrWriter = stderr
+
+	for i, as_json := range []bool{false, true} {
+		args := []string{
+			"appname",
+			"--package=foo",
+			"--module=example.com/foo",
+			"--output=" + filepath.Join(tdir, "binsanity.go"),
+			"--watch",
+			adir,
+		}
+		if as_json {
+			args = append(args[:5], "--json", adir)
+		}
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			binsanity.RunApp(args)
+		}()
+		sig := <-sigs
+		lines := func() int { return strings.Count(stdout.String(), "\n") }
+		start := lines()
+		waitFor(t, "first run", func() bool { return lines() == start+1 })
+		WriteTree(t, adir, map[string]string{"a": strings.Repeat("a", 4+i)})
+		waitFor(t, "second run", func() bool { return lines() == start+2 })
+		sig <- os.Interrupt
+		<-done
+	}
+
+	assert.Equal(0, exit_code, "exit 0")
+	assert.True(stopped, "stopped catching signals")
+	out := strings.Split(stdout.String(), "\n")
+	assert.Equal("files: 1, bytes: 3", out[0], "first")
+	assert.Equal("files: 1, bytes: 4", out[1], "second")
+	assert.True(strings.HasPrefix(out[2], `{"files":1,"bytes":4,`), "json")
+	assert.True(strings.HasPrefix(out[3], `{"files":1,"bytes":5,`), "json again")
+	assert.Equal("", stderr.String(), "stderr")
+
+}
+
+func TestRunAppWatchErrors(t *testing.T) {
+
+	assert := assert.New(t)
+
+	exit_code := 0
+	binsanity.ExitFunc = func(c int) { exit_code = c }
+	stderr := &bytes.Buffer{}
+	binsanity.OutWriter = &bytes.Buffer{}
+	binsanity.ErrWriter = stderr
+	defer RestoreDefaults()
+
+	binsanity.RunApp([]string{"appname", "--watch", "-c", "binsanity.json"})
+	assert.Equal(1, exit_code, "exit 1")
+	assert.Equal("Can't watch with a config file.\n", stderr.String(), "stderr")
+
+}
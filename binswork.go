@@ -0,0 +1,282 @@
+// binswork.go -- binsanity per-file work, spread over a pool of workers.
+
+package binsanity
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+var OsOpen = os.Open // used to read asset files, override for testing
+
+// Codec names the compression applied to asset data, as decoded by the
+// generated code.
+const Codec = "gzip"
+
+// NewCompressor returns the writer used to compress asset data into w.
+// Override for testing.
+//
+// The gzip header is set explicitly, with no name or time and an unknown
+// OS, so the same data always compresses to the same bytes.
+var NewCompressor = func(w io.Writer) io.WriteCloser {
+	zw := gzip.NewWriter(w)
+	zw.Header = gzip.Header{OS: 255}
+	return zw
+}
+
+// MaxBuffered is roughly how many bytes of encoded asset data may be held
+// in memory at once while generating.  Override for testing.
+var MaxBuffered = 8 << 20
+
+// bufferUnit is the granularity of MaxBuffered accounting.
+const bufferUnit = 64 << 10
+
+// readErrReader remembers any error other than EOF from reading r, so read
+// errors can be told apart from write errors in a copy.
+type readErrReader struct {
+	r   io.Reader
+	err error
+}
+
+func (rr *readErrReader) Read(p []byte) (int, error) {
+	n, err := rr.r.Read(p)
+	if err != nil && err != io.EOF {
+		rr.err = err
+	}
+	return n, err
+}
+
+// countingWriter counts the bytes written through it to w.
+type countingWriter struct {
+	w io.Writer
+	n int
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += n
+	return n, err
+}
+
+// workers returns the number of workers to use for n items given the jobs
+// requested.
+func workers(jobs, n int) int {
+	if jobs < 1 {
+		jobs = runtime.NumCPU()
+	}
+	if jobs > n {
+		jobs = n
+	}
+	return jobs
+}
+
+// fileData holds the size and sum of one file, and its compressed, encoded
+// data.
+type fileData struct {
+	size    int
+	sum     string
+	stored  int
+	encoded string
+	err     error
+}
+
+// encodeFile streams the file at path through gzip and base64, summing it
+// on the way, and returns its size and sum, the encoded data and the
+// compressed size.  The file is read once, and only the encoded data is held
+// in memory.
+func encodeFile(path string) *fileData {
+
+	f, err := OsOpen(path)
+	if err != nil {
+		return &fileData{err: &ProcessError{StageRead, path, err}}
+	}
+	defer f.Close()
+
+	// data is compressed, then encoded; sum is of raw bytes.
+	var sb strings.Builder
+	b64 := base64.NewEncoder(base64.StdEncoding, &sb)
+	counter := &countingWriter{w: b64}
+	writer := NewCompressor(counter)
+	h := sha256.New()
+
+	// Writing to a strings.Builder can't fail, so gzip can't either... but
+	// the compressor is swappable for testing, which is the whole point.
+	reader := &readErrReader{r: f}
+	n, err := io.Copy(writer, io.TeeReader(reader, h))
+	if err != nil {
+		if reader.err != nil {
+			return &fileData{err: &ProcessError{StageRead, path, err}}
+		}
+		return &fileData{err: &ProcessError{StageCompress, path, err}}
+	}
+	if err := writer.Close(); err != nil {
+		return &fileData{err: &ProcessError{StageCompress, path, err}}
+	}
+	b64.Close() // can't fail either, see above.
+
+	return &fileData{
+		size:    int(n),
+		sum:     fmt.Sprintf("%x", h.Sum(nil)),
+		stored:  counter.n,
+		encoded: sb.String(),
+	}
+
+}
+
+// encoding streams the encoded data of a list of files, in order, with
+// identical content only sent once.  The sizes, sums and data indexes of
+// the files are filled in as their data is sent or, for duplicates, passed
+// over, so are complete once the Data channel is closed without error.
+type encoding struct {
+	Data   <-chan string // encoded data, closed when done or failed
+	sizes  []int         // size of each file
+	sums   []string      // sha256 sum of each file
+	index  []int         // data index of each file
+	unique []string      // path of the file for each data index
+	stored []int         // compressed size for each data index
+	err    error         // first error encountered
+	stop   chan struct{}
+	once   sync.Once
+	wg     sync.WaitGroup
+}
+
+// encodeFiles calls encodeFile for every path using up to jobs workers, or
+// one per CPU if jobs is less than one, and sends the encoded data to the
+// Data channel in the order of paths, unless the same content was sent for
+// an earlier path.  The sizes of the files are used to estimate how big
+// their encoded data will be.
+//
+// Every file is read exactly once, summed as it is encoded.  Duplicates are
+// only known by their sums, so they are encoded too, and then dropped.
+//
+// At most jobs files are being encoded at once, and a file is only started
+// if its estimated data, with that of every file not yet received, fits in
+// MaxBuffered.  A file too big for that waits until nothing else is held,
+// so memory use is bounded by MaxBuffered or the largest encoded file, no
+// matter how many files or workers there are.
+//
+// On error the Data channel is closed early.  Callers must call Wait once
+// done with the channel, which returns the first error.
+func encodeFiles(paths []string, sizes []int, jobs int) *encoding {
+
+	jobs = workers(jobs, len(paths))
+	data := make(chan string)
+	enc := &encoding{
+		Data:   data,
+		sizes:  make([]int, len(paths)),
+		sums:   make([]string, len(paths)),
+		index:  make([]int, len(paths)),
+		unique: []string{},
+		stored: []int{},
+		stop:   make(chan struct{}),
+	}
+
+	// Results are collected in order from a queue of single-use channels.
+	// The semaphore keeps more than jobs from being in flight, and the
+	// buffer holds a token per bufferUnit of data not yet received.
+	sem := make(chan struct{}, jobs)
+	buffer := make(chan struct{}, bufferTokens(MaxBuffered))
+	queue := make(chan chan *fileData, len(paths))
+	units := make([]int, len(paths))
+
+	enc.wg.Add(2)
+	go func() {
+		defer enc.wg.Done()
+		defer close(queue)
+		for idx, path := range paths {
+			units[idx] = bufferTokens(base64.StdEncoding.EncodedLen(sizes[idx]))
+			if units[idx] > cap(buffer) {
+				units[idx] = cap(buffer)
+			}
+			for i := 0; i < units[idx]; i++ {
+				select {
+				case buffer <- struct{}{}:
+				case <-enc.stop:
+					return
+				}
+			}
+			select {
+			case sem <- struct{}{}:
+			case <-enc.stop:
+				return
+			}
+			ch := make(chan *fileData, 1)
+			queue <- ch
+			enc.wg.Add(1)
+			go func(path string, ch chan *fileData) {
+				defer enc.wg.Done()
+				ch <- encodeFile(path)
+			}(path, ch)
+		}
+	}()
+	go func() {
+		defer enc.wg.Done()
+		defer close(data)
+		seen := map[string]int{} // sum -> data index
+		idx := 0
+		for ch := range queue {
+			fd := <-ch
+			<-sem
+			if fd.err != nil {
+				enc.err = fd.err
+				enc.halt()
+				return
+			}
+			enc.sizes[idx] = fd.size
+			enc.sums[idx] = fd.sum
+
+			// Same content as something already sent?  Then point at that.
+			didx, found := seen[fd.sum]
+			if !found {
+				didx = len(enc.unique)
+				seen[fd.sum] = didx
+				enc.unique = append(enc.unique, paths[idx])
+				enc.stored = append(enc.stored, fd.stored)
+			}
+			enc.index[idx] = didx
+			if !found {
+				select {
+				case data <- fd.encoded:
+				case <-enc.stop:
+					return
+				}
+			}
+			for i := 0; i < units[idx]; i++ {
+				<-buffer
+			}
+			idx++
+		}
+	}()
+
+	return enc
+
+}
+
+// bufferTokens returns the number of bufferUnit tokens covering n bytes,
+// at least one.
+func bufferTokens(n int) int {
+	if n <= bufferUnit {
+		return 1
+	}
+	return (n + bufferUnit - 1) / bufferUnit
+}
+
+// halt stops any further work.
+func (enc *encoding) halt() {
+	enc.once.Do(func() { close(enc.stop) })
+}
+
+// Wait stops any further work, waits for the workers to finish and returns
+// the first error encountered.  Work in flight is discarded.
+func (enc *encoding) Wait() error {
+	enc.halt()
+	enc.wg.Wait()
+	return enc.err
+}
@@ -0,0 +1,251 @@
+// binswork_test.go - tests for stuff in binswork.go
+package binsanity_test
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/biztos/binsanity"
+)
+
+// manyFiles returns enough files to keep a bunch of workers busy.
+func manyFiles() map[string]string {
+	files := map[string]string{}
+	for i := 0; i < 50; i++ {
+		name := fmt.Sprintf("d%d/f%02d", i%4, i)
+		files[name] = strings.Repeat(name, i+1)
+	}
+	return files
+}
+
+func TestProcessJobsDeterministic(t *testing.T) {
+
+	assert := assert.New(t)
+
+	tdir := t.TempDir()
+	adir := filepath.Join(tdir, "assets")
+	WriteTree(t, adir, manyFiles())
+
+	outputs := []string{}
+	for _, jobs := range []int{1, 8, 0} {
+		file := filepath.Join(tdir, fmt.Sprintf("out%d.go", jobs))
+		cfg := &binsanity.Config{
+			Dir:     adir,
+			File:    file,
+			Package: "foo",
+			Module:  "example.com/foo",
+			Jobs:    jobs,
+		}
+		res, err := binsanity.Process(cfg)
+		if !assert.Nil(err, "no error for %d jobs", jobs) {
+			return
+		}
+		assert.Equal(50, res.Files, "files")
+		b, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The file name shows up in the header, which is fine.
+		outputs = append(outputs,
+			strings.Replace(string(b), filepath.Base(file), "out.go", 1))
+	}
+	assert.Equal(outputs[0], outputs[1], "1 vs 8 jobs")
+	assert.Equal(outputs[0], outputs[2], "1 vs default jobs")
+
+}
+
+func TestProcessJobsFirstError(t *testing.T) {
+
+	assert := assert.New(t)
+
+	tdir := t.TempDir()
+	adir := filepath.Join(tdir, "assets")
+	WriteTree(t, adir, manyFiles())
+
+	// Several files fail; we want the same one sequential processing would
+	// have reported, regardless of which worker got there first.
+	binsanity.OsOpen = func(path string) (*os.File, error) {
+		switch filepath.Base(path) {
+		case "f05", "f22", "f49":
+			return nil, errors.New("nope")
+		}
+		return os.Open(path)
+	}
+	defer RestoreDefaults()
+
+	for _, jobs := range []int{1, 3, 16} {
+		cfg := &binsanity.Config{
+			Dir:     adir,
+			File:    filepath.Join(tdir, "out.go"),
+			Package: "foo",
+			Module:  "example.com/foo",
+			Jobs:    jobs,
+		}
+		_, err := binsanity.Process(cfg)
+		assert.ErrorContains(err, "read "+filepath.Join(adir, "d1", "f05")+":",
+			"first error for %d jobs", jobs)
+	}
+
+}
+
+func TestProcessOpensOnce(t *testing.T) {
+
+	assert := assert.New(t)
+
+	// Duplicates too, and with so little buffered that every file waits.
+	tdir := t.TempDir()
+	adir := filepath.Join(tdir, "assets")
+	files := manyFiles()
+	files["dup/f07"] = files["d3/f07"]
+	files["dup/f42"] = files["d2/f42"]
+	WriteTree(t, adir, files)
+
+	var mutex sync.Mutex
+	opened := map[string]int{}
+	binsanity.OsOpen = func(path string) (*os.File, error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		opened[path]++
+		return os.Open(path)
+	}
+	binsanity.MaxBuffered = 1
+	defer RestoreDefaults()
+
+	for _, jobs := range []int{1, 8, 0} {
+		for _, dry := range []bool{false, true} {
+			opened = map[string]int{}
+			cfg := &binsanity.Config{
+				Dir:     adir,
+				File:    filepath.Join(tdir, "out.go"),
+				Package: "foo",
+				Module:  "example.com/foo",
+				Jobs:    jobs,
+				DryRun:  dry,
+			}
+			res, err := binsanity.Process(cfg)
+			if !assert.Nil(err, "no error for %d jobs", jobs) {
+				return
+			}
+			assert.Equal(len(files), res.Files, "files")
+			assert.Equal(len(files), len(opened), "all opened, %d jobs", jobs)
+			for path, n := range opened {
+				assert.Equal(1, n, "opened once: %s, %d jobs, dry run %v", path, jobs, dry)
+			}
+		}
+	}
+
+}
+
+func TestProcessErrReadFile(t *testing.T) {
+
+	assert := assert.New(t)
+
+	// A directory opens fine but can't be read.
+	tdir := t.TempDir()
+	adir := filepath.Join(tdir, "assets")
+	WriteTree(t, adir, map[string]string{"foo": "foo"})
+	binsanity.OsOpen = func(path string) (*os.File, error) {
+		return os.Open(tdir)
+	}
+	defer RestoreDefaults()
+
+	cfg := &binsanity.Config{
+		Dir:     adir,
+		File:    filepath.Join(tdir, "out.go"),
+		Package: "foo",
+		Module:  "example.com/foo",
+	}
+	_, err := binsanity.Process(cfg)
+	assert.ErrorContains(err, "read "+filepath.Join(adir, "foo")+":")
+
+}
+
+func TestProcessMemoryBounded(t *testing.T) {
+
+	if testing.Short() {
+		t.Skip("slow: writes and processes a large tree")
+	}
+	assert := assert.New(t)
+
+	// Incompressible files so the data can't shrink its way under the limit.
+	const count = 48
+	const size = 1 << 20
+	tdir := t.TempDir()
+	adir := filepath.Join(tdir, "assets")
+	if err := os.MkdirAll(adir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	rnd := rand.New(rand.NewSource(1))
+	b := make([]byte, size)
+	for i := 0; i < count; i++ {
+		rnd.Read(b)
+		path := filepath.Join(adir, fmt.Sprintf("big%02d", i))
+		if err := os.WriteFile(path, b, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	b = nil
+
+	// Keep garbage from piling up so we see what is really held, then watch
+	// the heap while processing, with a worker per CPU and with far more
+	// workers than the data held at once allows for.
+	defer debug.SetGCPercent(debug.SetGCPercent(10))
+	for _, jobs := range []int{0, 32} {
+		runtime.GC()
+		var before runtime.MemStats
+		runtime.ReadMemStats(&before)
+
+		var peak uint64
+		done := make(chan struct{})
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var ms runtime.MemStats
+			for {
+				runtime.ReadMemStats(&ms)
+				if ms.HeapInuse > peak {
+					peak = ms.HeapInuse
+				}
+				select {
+				case <-done:
+					return
+				case <-time.After(time.Millisecond):
+				}
+			}
+		}()
+
+		cfg := &binsanity.Config{
+			Dir:     adir,
+			File:    filepath.Join(tdir, "big.go"),
+			Package: "foo",
+			Module:  "example.com/foo",
+			Jobs:    jobs,
+		}
+		res, err := binsanity.Process(cfg)
+		close(done)
+		wg.Wait()
+		if !assert.Nil(err, "no error") {
+			return
+		}
+		assert.Equal(count*size, res.Bytes, "bytes")
+
+		// Everything at once would be over 64MB encoded; MaxBuffered of it
+		// plus compressor state is well under a third of that.
+		used := int64(peak) - int64(before.HeapInuse)
+		assert.True(used < 20<<20, "heap growth %d with %d jobs should be under 20MB",
+			used, jobs)
+	}
+
+}
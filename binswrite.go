@@ -0,0 +1,330 @@
+// binswrite.go -- binsanity all-or-nothing writing of generated files.
+
+package binsanity
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+// GeneratedMarker is the first line of every file binsanity generates,
+// following the Go convention for generated code.
+const GeneratedMarker = "// Code generated by binsanity; DO NOT EDIT."
+
+// ErrNotGenerated is the error for an output file that would be overwritten
+// but was not generated by binsanity.
+var ErrNotGenerated = errors.New("Not generated by binsanity, will not overwrite.")
+
+var OsRename = os.Rename // used to put generated files in place, override for testing
+
+// legacyHeader matches the first line of files from binsanity versions that
+// did not yet write the marker.
+var legacyHeader = regexp.MustCompile(`^/\* \S.* - auto-generated; edit at your own peril!$`)
+
+// IsGenerated returns true if the file at path was generated by binsanity,
+// judging by its first line.  Files from versions before GeneratedMarker was
+// added are recognized too.  A file that does not exist returns an error.
+func IsGenerated(path string) (bool, error) {
+
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	line, err := bufio.NewReader(f).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false, err
+	}
+	line = strings.TrimRight(line, "\r\n")
+	return line == GeneratedMarker || legacyHeader.MatchString(line), nil
+
+}
+
+// checkOverwrite returns an error if any of the files at paths exists and
+// was not generated by binsanity.
+func checkOverwrite(paths ...string) error {
+
+	for _, path := range paths {
+		ok, err := IsGenerated(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return &ProcessError{StageWrite, path, err}
+		}
+		if !ok {
+			return &ProcessError{StageWrite, path, ErrNotGenerated}
+		}
+	}
+	return nil
+
+}
+
+// compareWriter compares what is written to it with the content of an
+// existing file as it goes, so neither has to be held in memory.
+type compareWriter struct {
+	f    *os.File
+	r    *bufio.Reader
+	buf  []byte
+	diff bool
+}
+
+// newCompareWriter returns a compareWriter for the file at path.  If it
+// can't be opened it is simply different.
+func newCompareWriter(path string) *compareWriter {
+	f, err := os.Open(path)
+	if err != nil {
+		return &compareWriter{diff: true}
+	}
+	return &compareWriter{f: f, r: bufio.NewReader(f)}
+}
+
+// Write compares p with the next len(p) bytes of the file.  It never fails.
+func (cw *compareWriter) Write(p []byte) (int, error) {
+	if !cw.diff {
+		if cap(cw.buf) < len(p) {
+			cw.buf = make([]byte, len(p))
+		}
+		buf := cw.buf[:len(p)]
+		if _, err := io.ReadFull(cw.r, buf); err != nil || !bytes.Equal(buf, p) {
+			cw.diff = true
+		}
+	}
+	return len(p), nil
+}
+
+// Same closes the file and returns true if what was written matched all of
+// it.
+func (cw *compareWriter) Same() bool {
+	if cw.f == nil {
+		return false
+	}
+	defer cw.f.Close()
+	if cw.diff {
+		return false
+	}
+	_, err := cw.r.ReadByte()
+	return err == io.EOF
+}
+
+// fileSet collects new versions of files, rendered into temporary files in
+// the same directories, to be put in place only once all of them are done.
+// Until then the existing files are untouched, and files whose content has
+// not changed are never touched at all.
+//
+// If check is set, nothing is written at all: the rendered output is only
+// compared with the existing files.
+type fileSet struct {
+	check bool
+	files []*pendingFile
+}
+
+// pendingFile is a temporary file waiting to replace the one at path, and a
+// comparison with it; in check mode just the comparison.
+type pendingFile struct {
+	path   string
+	tmp    *os.File
+	cmp    *compareWriter
+	size   int    // bytes rendered
+	backup string // where the existing file goes while replacing it
+}
+
+// Create returns a writer for the new content of path: a temporary file to
+// be renamed to path on Commit, if different, or in check mode only a
+// comparison.
+func (set *fileSet) Create(path string) (io.Writer, error) {
+
+	if set.check {
+		cmp := newCompareWriter(path)
+		set.files = append(set.files, &pendingFile{path: path, cmp: cmp})
+		return cmp, nil
+	}
+	dir, base := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+	tmp, err := os.CreateTemp(dir, "."+base+".*.tmp")
+	if err != nil {
+		return nil, &ProcessError{StageWrite, path, err}
+	}
+	cmp := newCompareWriter(path)
+	set.files = append(set.files, &pendingFile{path: path, tmp: tmp, cmp: cmp})
+	return io.MultiWriter(tmp, cmp), nil
+
+}
+
+// Differs returns the paths of the files whose new content, as rendered so
+// far, differs from what is there.  It is only meaningful in check mode,
+// once rendering is done.
+func (set *fileSet) Differs() []string {
+
+	paths := []string{}
+	for _, pf := range set.files {
+		if !pf.cmp.Same() {
+			paths = append(paths, pf.path)
+		}
+	}
+	return paths
+
+}
+
+// Size returns the total number of bytes rendered so far.
+func (set *fileSet) Size() int {
+
+	size := 0
+	for _, pf := range set.files {
+		size += pf.size
+	}
+	return size
+
+}
+
+// Render executes tmpl with data into a temporary file for path, and counts
+// the bytes rendered.
+func (set *fileSet) Render(path string, tmpl *template.Template, data interface{}) error {
+
+	f, err := set.Create(path)
+	if err != nil {
+		return err
+	}
+	counter := &countingWriter{w: f}
+	w := bufio.NewWriter(counter)
+	if err := tmpl.Execute(w, data); err != nil {
+		return &ProcessError{StageRender, path, err}
+	}
+	if err := w.Flush(); err != nil {
+		return &ProcessError{StageWrite, path, err}
+	}
+	set.files[len(set.files)-1].size = counter.n
+	return nil
+
+}
+
+// Commit closes the temporary files and renames them into place, in the
+// order they were created, keeping the mode of any file being replaced.  New
+// files get the mode os.Create would give them, 0666 less the umask.  Files
+// identical to what is already there are left alone, and their temporary
+// files removed.  The paths of the files written are returned.
+//
+// Everything that can be checked is checked before the first rename: if
+// closing any file fails, or any target is a directory, nothing is renamed.
+// Existing files are renamed aside before being replaced, so if a rename
+// fails after all, those already done are rolled back.
+func (set *fileSet) Commit() ([]string, error) {
+
+	changed := []*pendingFile{}
+	for _, pf := range set.files {
+		if err := pf.tmp.Close(); err != nil {
+			return nil, &ProcessError{StageWrite, pf.path, err}
+		}
+		if pf.cmp.Same() {
+			os.Remove(pf.tmp.Name())
+			continue
+		}
+		changed = append(changed, pf)
+		var mode os.FileMode
+		if info, err := os.Stat(pf.path); err == nil {
+			if info.IsDir() {
+				return nil, &ProcessError{StageWrite, pf.path, errors.New("Not a file")}
+			}
+			mode = info.Mode().Perm()
+			pf.backup = pf.tmp.Name() + ".old"
+		} else if mode, err = newFileMode(pf.tmp.Name() + ".new"); err != nil {
+			return nil, &ProcessError{StageWrite, pf.path, err}
+		}
+		if err := os.Chmod(pf.tmp.Name(), mode); err != nil {
+			return nil, &ProcessError{StageWrite, pf.path, err}
+		}
+	}
+	written := []string{}
+	for idx, pf := range changed {
+		if err := pf.replace(); err != nil {
+			for _, done := range changed[:idx] {
+				done.restore()
+			}
+			return nil, &ProcessError{StageWrite, pf.path, err}
+		}
+		written = append(written, pf.path)
+	}
+	for _, pf := range changed {
+		if pf.backup != "" {
+			os.Remove(pf.backup)
+		}
+	}
+	set.files = nil
+	return written, nil
+
+}
+
+// newFileMode returns the mode os.Create gives a new file, 0666 less the
+// umask, by creating one at path and removing it again.
+func newFileMode(path string) (os.FileMode, error) {
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0666)
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(path)
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	return info.Mode().Perm(), nil
+
+}
+
+// replace renames the file at path aside to its backup, if it has one, and
+// the temporary file to path.  On error the file at path is as it was.
+func (pf *pendingFile) replace() error {
+
+	if pf.backup != "" {
+		if err := OsRename(pf.path, pf.backup); err != nil {
+			return err
+		}
+	}
+	if err := OsRename(pf.tmp.Name(), pf.path); err != nil {
+		if pf.backup != "" {
+			os.Rename(pf.backup, pf.path)
+		}
+		return err
+	}
+	return nil
+
+}
+
+// restore undoes replace, putting the backup back or removing the new file
+// if there was none.
+func (pf *pendingFile) restore() {
+
+	if pf.backup != "" {
+		os.Rename(pf.backup, pf.path)
+	} else {
+		os.Remove(pf.path)
+	}
+
+}
+
+// Abort closes and removes any temporary files not yet committed.  It is
+// safe to call after Commit, so it can be deferred.
+func (set *fileSet) Abort() {
+
+	for _, pf := range set.files {
+		if pf.tmp != nil {
+			pf.tmp.Close()
+			os.Remove(pf.tmp.Name())
+		}
+		if pf.cmp != nil && pf.cmp.f != nil {
+			pf.cmp.f.Close()
+		}
+	}
+	set.files = nil
+
+}
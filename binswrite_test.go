@@ -0,0 +1,370 @@
+// binswrite_test.go - tests for stuff in binswrite.go
+package binsanity_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/biztos/binsanity"
+)
+
+// readAll returns the names and contents of the files in dir.
+func readAll(t *testing.T, dir string) map[string]string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		b, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[entry.Name()] = string(b)
+	}
+	return files
+}
+
+func TestProcessAtomicEncodeFails(t *testing.T) {
+
+	assert := assert.New(t)
+
+	tdir := t.TempDir()
+	adir := filepath.Join(tdir, "assets")
+	WriteTree(t, adir, manyFiles())
+	odir := filepath.Join(tdir, "out")
+	if err := os.Mkdir(odir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, split := range []int{0, 500} {
+
+		cfg := &binsanity.Config{
+			Dir:       adir,
+			File:      filepath.Join(odir, "binsanity.go"),
+			Package:   "foo",
+			Module:    "example.com/foo",
+			SplitSize: split,
+		}
+		_, err := binsanity.Process(cfg)
+		if !assert.Nil(err, "no error first time") {
+			return
+		}
+		before := readAll(t, odir)
+
+		// Change an asset so there is something new to write, then fail
+		// late: a file well down the list is opened long after the code file
+		// was started.
+		WriteTree(t, adir, map[string]string{"d2/f30": "changed"})
+		binsanity.OsOpen = func(path string) (*os.File, error) {
+			if filepath.Base(path) == "f30" {
+				return os.Open(filepath.Join(tdir, "nonesuch"))
+			}
+			return os.Open(path)
+		}
+		_, err = binsanity.Process(cfg)
+		RestoreDefaults()
+		assert.ErrorContains(err, "read "+filepath.Join(adir, "d2", "f30")+":")
+		assert.Equal(before, readAll(t, odir), "files untouched, split %d", split)
+
+	}
+
+}
+
+func TestProcessAtomicCommitFails(t *testing.T) {
+
+	assert := assert.New(t)
+
+	tdir := t.TempDir()
+	adir := filepath.Join(tdir, "assets")
+	WriteTree(t, adir, manyFiles())
+
+	// Something in the way of the test file.
+	file := filepath.Join(tdir, "binsanity.go")
+	WriteTree(t, tdir, map[string]string{
+		"binsanity.go":              "old code",
+		"binsanity_test.go/nothing": "in the way",
+	})
+
+	cfg := &binsanity.Config{
+		Dir:     adir,
+		File:    file,
+		Package: "foo",
+		Module:  "example.com/foo",
+		Force:   true,
+	}
+	_, err := binsanity.Process(cfg)
+	assert.EqualError(err, "write "+filepath.Join(tdir, "binsanity_test.go")+": Not a file")
+	assert.Equal(map[string]string{"binsanity.go": "old code"}, readAll(t, tdir))
+
+}
+
+func TestProcessAtomicRenameFails(t *testing.T) {
+
+	assert := assert.New(t)
+
+	tdir := t.TempDir()
+	adir := filepath.Join(tdir, "assets")
+	WriteTree(t, adir, manyFiles())
+	file := filepath.Join(tdir, "binsanity.go")
+	tfile := filepath.Join(tdir, "binsanity_test.go")
+
+	for _, split := range []int{0, 500} {
+
+		cfg := &binsanity.Config{
+			Dir:       adir,
+			File:      file,
+			Package:   "foo",
+			Module:    "example.com/foo",
+			SplitSize: split,
+		}
+		_, err := binsanity.Process(cfg)
+		if !assert.Nil(err, "no error first time") {
+			return
+		}
+		before := readAll(t, tdir)
+
+		// The test file is put in place last, after the code file and any
+		// data files, so failing either of its renames undoes the others.
+		WriteTree(t, adir, map[string]string{"d2/f30": fmt.Sprintf("changed %d", split)})
+		for _, aside := range []bool{true, false} {
+			binsanity.OsRename = func(oldpath, newpath string) error {
+				if (aside && oldpath == tfile) || (!aside && newpath == tfile) {
+					return errors.New("rename failed")
+				}
+				return os.Rename(oldpath, newpath)
+			}
+			_, err = binsanity.Process(cfg)
+			RestoreDefaults()
+			assert.EqualError(err, "write "+tfile+": rename failed")
+			assert.Equal(before, readAll(t, tdir), "rolled back, split %d", split)
+		}
+
+	}
+
+}
+
+func TestProcessAtomicKeepsMode(t *testing.T) {
+
+	assert := assert.New(t)
+
+	tdir := t.TempDir()
+	adir := filepath.Join(tdir, "assets")
+	WriteTree(t, adir, map[string]string{"foo": "foo"})
+	file := filepath.Join(tdir, "binsanity.go")
+	tfile := filepath.Join(tdir, "binsanity_test.go")
+	WriteTree(t, tdir, map[string]string{"binsanity.go": "old code"})
+	if err := os.Chmod(file, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &binsanity.Config{
+		Dir:     adir,
+		File:    file,
+		Package: "foo",
+		Module:  "example.com/foo",
+		Force:   true,
+	}
+	_, err := binsanity.Process(cfg)
+	if !assert.Nil(err, "no error") {
+		return
+	}
+	// New files get what os.Create gives them, whatever the umask.
+	probe, err := os.Create(filepath.Join(tdir, "probe"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	probe.Close()
+	info, err := os.Stat(probe.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, mode := range map[string]os.FileMode{file: 0600, tfile: info.Mode().Perm()} {
+		info, err := os.Stat(path)
+		if assert.Nil(err, "stat") {
+			assert.Equal(mode, info.Mode().Perm(), path)
+		}
+	}
+
+}
+
+func TestProcessErrCreateTemp(t *testing.T) {
+
+	assert := assert.New(t)
+
+	cfg := &binsanity.Config{
+		Dir:     ExampleAssetDir,
+		File:    filepath.Join(NonesuchDir, "binsanity.go"),
+		Package: "foo",
+		Module:  "example.com/foo",
+	}
+	_, err := binsanity.Process(cfg)
+	assert.ErrorIs(err, os.ErrNotExist)
+
+}
+
+func TestIsGenerated(t *testing.T) {
+
+	assert := assert.New(t)
+
+	tdir := t.TempDir()
+	WriteTree(t, tdir, map[string]string{
+		"marker.go":  binsanity.GeneratedMarker + "\n\npackage foo\n",
+		"crlf.go":    binsanity.GeneratedMarker + "\r\n\r\npackage foo\r\n",
+		"only.go":    binsanity.GeneratedMarker,
+		"legacy.go":  "/* legacy.go - auto-generated; edit at your own peril!\n",
+		"other.go":   "// Code generated by go-bindata. DO NOT EDIT.\n",
+		"mine.go":    "package foo\n",
+		"empty.go":   "",
+		"dir.go/foo": "not a file",
+	})
+
+	for name, exp := range map[string]bool{
+		"marker.go": true,
+		"crlf.go":   true,
+		"only.go":   true,
+		"legacy.go": true,
+		"other.go":  false,
+		"mine.go":   false,
+		"empty.go":  false,
+	} {
+		got, err := binsanity.IsGenerated(filepath.Join(tdir, name))
+		if assert.Nil(err, name) {
+			assert.Equal(exp, got, name)
+		}
+	}
+
+	_, err := binsanity.IsGenerated(filepath.Join(tdir, "nonesuch.go"))
+	assert.ErrorIs(err, os.ErrNotExist, "nonesuch")
+	_, err = binsanity.IsGenerated(filepath.Join(tdir, "dir.go"))
+	assert.ErrorContains(err, "is a directory", "dir")
+
+}
+
+func TestProcessRefusesOverwrite(t *testing.T) {
+
+	tdir := t.TempDir()
+	adir := filepath.Join(tdir, "assets")
+	WriteTree(t, adir, map[string]string{"foo": "foo"})
+
+	for _, name := range []string{
+		"binsanity.go",
+		"binsanity_test.go",
+		"binsanity_data_002.go",
+	} {
+		t.Run(name, func(t *testing.T) {
+
+			assert := assert.New(t)
+
+			odir := filepath.Join(tdir, "out", name)
+			file := filepath.Join(odir, "binsanity.go")
+			path := filepath.Join(odir, name)
+			WriteTree(t, odir, map[string]string{name: "package main\n"})
+			cfg := &binsanity.Config{
+				Dir:     adir,
+				File:    file,
+				Package: "foo",
+				Module:  "example.com/foo",
+			}
+			_, err := binsanity.Process(cfg)
+			var perr *binsanity.ProcessError
+			if assert.True(errors.As(err, &perr), "ProcessError") {
+				assert.Equal(binsanity.StageWrite, perr.Stage, "stage")
+				assert.Equal(path, perr.File, "file")
+			}
+			assert.ErrorIs(err, binsanity.ErrNotGenerated)
+			b, _ := os.ReadFile(path)
+			assert.Equal("package main\n", string(b), "untouched")
+
+			// Forcing it works, and then there is no more problem.
+			cfg.Force = true
+			_, err = binsanity.Process(cfg)
+			assert.Nil(err, "forced")
+			cfg.Force = false
+			_, err = binsanity.Process(cfg)
+			assert.Nil(err, "generated now")
+			assert.NoFileExists(filepath.Join(odir, "binsanity_data_002.go"))
+
+		})
+	}
+
+}
+
+func TestProcessSkipsUnchanged(t *testing.T) {
+
+	assert := assert.New(t)
+
+	tdir := t.TempDir()
+	adir := filepath.Join(tdir, "assets")
+	WriteTree(t, adir, map[string]string{"a": "aaa", "b": "bbb"})
+	cfg := &binsanity.Config{
+		Dir:     adir,
+		File:    filepath.Join(tdir, "binsanity.go"),
+		Package: "foo",
+		Module:  "example.com/foo",
+	}
+	tfile := filepath.Join(tdir, "binsanity_test.go")
+	res, err := binsanity.Process(cfg)
+	if !assert.Nil(err, "no error") {
+		return
+	}
+	assert.Equal([]string{cfg.File, tfile}, res.Written, "written")
+
+	// Backdate them so any rewrite would show.
+	old := time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC)
+	for _, path := range []string{cfg.File, tfile} {
+		if err := os.Chtimes(path, old, old); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mtime := func(path string) time.Time {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return info.ModTime()
+	}
+
+	res, err = binsanity.Process(cfg)
+	if !assert.Nil(err, "no error") {
+		return
+	}
+	assert.Equal([]string{}, res.Written, "nothing written")
+	assert.True(mtime(cfg.File).Equal(old), "code untouched")
+	assert.True(mtime(tfile).Equal(old), "tests untouched")
+	assert.Equal([]string{"binsanity.go", "binsanity_test.go"},
+		keys(readAll(t, tdir)), "no temp files left")
+
+	// Only what is out of date gets written.
+	WriteTree(t, tdir, map[string]string{
+		"binsanity_test.go": binsanity.GeneratedMarker + "\nstale\n",
+	})
+	res, err = binsanity.Process(cfg)
+	if !assert.Nil(err, "no error") {
+		return
+	}
+	assert.Equal([]string{tfile}, res.Written, "tests written")
+	assert.True(mtime(cfg.File).Equal(old), "code untouched")
+	assert.False(mtime(tfile).Equal(old), "tests rewritten")
+
+}
+
+// keys returns the sorted keys of m.
+func keys(m map[string]string) []string {
+	list := []string{}
+	for k := range m {
+		list = append(list, k)
+	}
+	sort.Strings(list)
+	return list
+}
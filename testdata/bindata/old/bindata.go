@@ -0,0 +1,82 @@
+package assets
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+)
+
+func bindata_read(data []byte, name string) ([]byte, error) {
+	gz, err := gzip.NewReader(bytes.NewBuffer(data))
+	if err != nil {
+		return nil, fmt.Errorf("Read %q: %v", name, err)
+	}
+
+	var buf bytes.Buffer
+	_, err = io.Copy(&buf, gz)
+	gz.Close()
+
+	if err != nil {
+		return nil, fmt.Errorf("Read %q: %v", name, err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+func assets_empty_txt() ([]byte, error) {
+	return bindata_read([]byte{
+		0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0x03, 0x03, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	},
+		"assets/empty.txt",
+	)
+}
+
+func assets_hello_txt() ([]byte, error) {
+	return bindata_read([]byte{
+		0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0x03, 0xcb, 0x48,
+		0xcd, 0xc9, 0xc9, 0xd7, 0x51, 0x28, 0xcf, 0x2f, 0xca, 0x49, 0xe1, 0x02,
+		0x00, 0x53, 0x74, 0x24, 0xf4, 0x0d, 0x00, 0x00, 0x00,
+	},
+		"assets/hello.txt",
+	)
+}
+
+func assets_img_dot_bin() ([]byte, error) {
+	return bindata_read([]byte{
+		0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0x03, 0xeb, 0x0c,
+		0xf0, 0x73, 0x67, 0x50, 0x8a, 0xe1, 0xfa, 0x0f, 0x00, 0xea, 0xd2, 0xc0,
+		0xc7, 0x09, 0x00, 0x00, 0x00,
+	},
+		"assets/img/dot.bin",
+	)
+}
+
+func assets_sub_data_json() ([]byte, error) {
+	return bindata_read([]byte{
+		0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0x03, 0xab, 0x56,
+		0x4a, 0xcc, 0x2b, 0x2e, 0x4f, 0x2d, 0x52, 0xb2, 0x52, 0x30, 0x31, 0xaa,
+		0xe5, 0x02, 0x00, 0xcf, 0x14, 0x43, 0xa4, 0x0f, 0x00, 0x00, 0x00,
+	},
+		"assets/sub/data.json",
+	)
+}
+
+// Asset loads and returns the asset for the given name.
+// It returns an error if the asset could not be found or
+// could not be loaded.
+func Asset(name string) ([]byte, error) {
+	if f, ok := _bindata[name]; ok {
+		return f()
+	}
+	return nil, fmt.Errorf("Asset %s not found", name)
+}
+
+// _bindata is a table, holding each asset generator, mapped to its name.
+var _bindata = map[string]func() ([]byte, error){
+	"assets/empty.txt":     assets_empty_txt,
+	"assets/hello.txt":     assets_hello_txt,
+	"assets/img/dot.bin":   assets_img_dot_bin,
+	"assets/sub/data.json": assets_sub_data_json,
+}
@@ -0,0 +1,172 @@
+/* binsanity_test.go - auto-generated; edit at your own peril!
+
+To test the checksums for all content, set the environment variable
+BINSANITY_TEST_CONTENT to one of: Y,YES,T,TRUE,1 (the Truthy Shortlist).
+
+More info: https://github.com/biztos/binsanity
+
+*/
+
+package main_test
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"biztos.com/example"
+)
+
+const BinsanityAssetMissing = "foo--NOPE"
+const BinsanityAssetPresent = "baz/bat/bloopf"
+const BinsanityAssetPresentSum = "4fed59a4fcf97d28970b4a46c5eb52fc6ce9336fab8248073a6ba7c7b49aef59"
+
+var BinsanityAssetNames = []string{
+
+	"bar",
+	"baz/bat/bloopf",
+	"foo",
+}
+
+var BinsanityAssetSums = []string{
+	"45aab2edbbcaeaea205a0789171a3a6841296b18e3594b706bfd4f30debfd89f",
+	"4fed59a4fcf97d28970b4a46c5eb52fc6ce9336fab8248073a6ba7c7b49aef59",
+	"782507ceeb536a4051b22c4941aade018d777e3511dfc27598574ab328126112",
+}
+
+func TestAssetNames(t *testing.T) {
+
+	names := main.AssetNames()
+	if len(names) != len(BinsanityAssetNames) {
+		t.Fatalf("Wrong number of names:\n  expected: %d\n  actual: %d",
+			len(BinsanityAssetNames), len(names))
+	}
+
+	// ...moments when you really miss Testify... but NO deps for the
+	// generated files!
+	for idx, n := range names {
+		if n != BinsanityAssetNames[idx] {
+			t.Fatalf("Mismatch at %d:\n  expected: %s\n  actual: %s",
+				idx, BinsanityAssetNames[idx], n)
+		}
+	}
+
+}
+
+func TestAssetNotFound(t *testing.T) {
+
+	_, err := main.Asset(BinsanityAssetMissing)
+	if err == nil {
+		t.Fatal("No error for missing asset.")
+	}
+	if err.Error() != "Asset not found." {
+		t.Fatal("Wrong error for missing asset.")
+	}
+}
+
+func TestAssetFound(t *testing.T) {
+
+	b, err := main.Asset(BinsanityAssetPresent)
+	if err != nil {
+		t.Fatal("Error for asset that should not be missing.")
+	}
+	sum := fmt.Sprintf("%x", sha256.Sum256(b))
+	if sum != BinsanityAssetPresentSum {
+		t.Fatal("Wrong sha256 sum for asset data.")
+	}
+}
+
+func TestMustAssetNotFound(t *testing.T) {
+
+	exp := "Asset not found."
+	panicky := func() { main.MustAssetString(BinsanityAssetMissing) }
+	AssertPanicsWith(t, panicky, exp, "MustAsset (not found)")
+
+}
+
+func TestMustAssetFound(t *testing.T) {
+
+	b := main.MustAsset(BinsanityAssetPresent)
+	sum := fmt.Sprintf("%x", sha256.Sum256(b))
+	if sum != BinsanityAssetPresentSum {
+		t.Fatal("Wrong sha256 sum for asset data.")
+	}
+
+}
+
+func TestMustAssetStringNotFound(t *testing.T) {
+
+	exp := "Asset not found."
+	panicky := func() { main.MustAssetString(BinsanityAssetMissing) }
+	AssertPanicsWith(t, panicky, exp, "MustAssetString (not found)")
+
+}
+
+func TestMustAssetStringFound(t *testing.T) {
+
+	s := main.MustAssetString(BinsanityAssetPresent)
+	sum := fmt.Sprintf("%x", sha256.Sum256([]byte(s)))
+	if sum != BinsanityAssetPresentSum {
+		t.Fatal("Wrong sha256 sum for asset data.")
+	}
+
+}
+
+func TestAssetSums(t *testing.T) {
+	var want_tests bool
+	// This is a little bit overkill but people have habits right?
+	boolish := map[string]bool{
+		"Y":    true,
+		"YES":  true,
+		"T":    true,
+		"TRUE": true,
+		"1":    true,
+	}
+	flag := strings.ToUpper(os.Getenv("BINSANITY_TEST_CONTENT"))
+	want_tests = boolish[flag]
+	if !want_tests {
+		t.Skip()
+		return
+	}
+	for idx, name := range BinsanityAssetNames {
+		b, err := main.Asset(name)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		exp := BinsanityAssetSums[idx]
+		sum := fmt.Sprintf("%x", sha256.Sum256(b))
+		if sum != exp {
+			t.Fatalf("Wrong sha256 sum for data of: %s\n  expected: %s\n    actual: %s",
+				name, exp, sum)
+		}
+	}
+}
+
+// For a more useful version of this see: https://github.com/biztos/testig
+func AssertPanicsWith(t *testing.T, f func(), exp string, msg string) {
+
+	panicked := false
+	got := ""
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				panicked = true
+				got = fmt.Sprintf("%s", r)
+			}
+		}()
+		f()
+	}()
+
+	if !panicked {
+		t.Fatalf("Function did not panic: %s", msg)
+	} else if got != exp {
+
+		t.Fatalf("Panic not as expected: %s\n  expected: %s\n    actual: %s",
+			msg, exp, got)
+	}
+
+	// (In go testing, success is silent.)
+
+}